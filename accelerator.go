@@ -0,0 +1,275 @@
+package darwin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Accelerator is a menu item's keyboard shortcut as AppKit stores it: the
+// key equivalent string and an NSEventModifierFlag mask.
+type Accelerator struct {
+	Key       string
+	Modifiers uintptr
+}
+
+// Unicode values AppKit uses for keys without a printable character, from
+// NSEvent.h.
+const (
+	NSUpArrowFunctionKey    = 0xF700
+	NSDownArrowFunctionKey  = 0xF701
+	NSLeftArrowFunctionKey  = 0xF702
+	NSRightArrowFunctionKey = 0xF703
+	NSF1FunctionKey         = 0xF704
+	NSF35FunctionKey        = 0xF726
+	NSInsertFunctionKey     = 0xF727
+	NSDeleteFunctionKey     = 0xF728
+	NSHomeFunctionKey       = 0xF729
+	NSEndFunctionKey        = 0xF72B
+	NSPageUpFunctionKey     = 0xF72C
+	NSPageDownFunctionKey   = 0xF72D
+	NSHelpFunctionKey       = 0xF746
+
+	nsBackspaceCharacter = 0x08
+	nsTabCharacter       = 0x09
+	nsCarriageReturn     = 0x0D
+	nsEscapeCharacter    = 0x1B
+)
+
+var acceleratorModifiers = map[string]uintptr{
+	"cmd":              NSEventModifierFlagCommand,
+	"command":          NSEventModifierFlagCommand,
+	"cmdorctrl":        NSEventModifierFlagCommand,
+	"commandorcontrol": NSEventModifierFlagCommand,
+	"ctrl":             NSEventModifierFlagControl,
+	"control":          NSEventModifierFlagControl,
+	"alt":              NSEventModifierFlagOption,
+	"opt":              NSEventModifierFlagOption,
+	"option":           NSEventModifierFlagOption,
+	"shift":            NSEventModifierFlagShift,
+}
+
+// acceleratorKeys maps named keys to their key equivalents. The Mac Delete
+// key is backspace; the key labelled Delete on extended keyboards is
+// ForwardDelete.
+var acceleratorKeys = map[string]rune{
+	"plus":          '+',
+	"minus":         '-',
+	"space":         ' ',
+	"tab":           nsTabCharacter,
+	"return":        nsCarriageReturn,
+	"enter":         nsCarriageReturn,
+	"esc":           nsEscapeCharacter,
+	"escape":        nsEscapeCharacter,
+	"delete":        nsBackspaceCharacter,
+	"backspace":     nsBackspaceCharacter,
+	"forwarddelete": NSDeleteFunctionKey,
+	"insert":        NSInsertFunctionKey,
+	"help":          NSHelpFunctionKey,
+	"up":            NSUpArrowFunctionKey,
+	"down":          NSDownArrowFunctionKey,
+	"left":          NSLeftArrowFunctionKey,
+	"right":         NSRightArrowFunctionKey,
+	"home":          NSHomeFunctionKey,
+	"end":           NSEndFunctionKey,
+	"pageup":        NSPageUpFunctionKey,
+	"pagedown":      NSPageDownFunctionKey,
+}
+
+// ParseAccelerator parses a shortcut such as "Cmd+Shift+S", "Ctrl+Alt+F5",
+// "Cmd+Plus" or "Option+Delete". Names are case-insensitive and joined by
+// "+"; the last one is the key and the rest are modifiers. A letter key
+// is stored in lower case, so Shift must be named to require it.
+func ParseAccelerator(s string) (Accelerator, error) {
+	parts := strings.Split(s, "+")
+	// "Cmd++" names the plus key directly.
+	if len(parts) >= 2 && parts[len(parts)-1] == "" && parts[len(parts)-2] == "" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	var a Accelerator
+	for i, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			return Accelerator{}, fmt.Errorf("%w: %q: empty name", ErrInvalidAccelerator, s)
+		}
+		if i < len(parts)-1 {
+			mod, ok := acceleratorModifiers[name]
+			if !ok {
+				return Accelerator{}, fmt.Errorf("%w: %q: unknown modifier %q", ErrInvalidAccelerator, s, part)
+			}
+			a.Modifiers |= mod
+			continue
+		}
+		key, ok := acceleratorKey(name)
+		if !ok {
+			return Accelerator{}, fmt.Errorf("%w: %q: unknown key %q", ErrInvalidAccelerator, s, part)
+		}
+		a.Key = string(key)
+	}
+	return a, nil
+}
+
+func acceleratorKey(name string) (rune, bool) {
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && r != utf8.RuneError {
+		return unicode.ToLower(r), true
+	}
+	if r, ok := acceleratorKeys[name]; ok {
+		return r, true
+	}
+	if digits, ok := strings.CutPrefix(name, "f"); ok {
+		n, err := strconv.Atoi(digits)
+		if err == nil && digits[0] != '0' && n >= 1 && n <= NSF35FunctionKey-NSF1FunctionKey+1 {
+			return rune(NSF1FunctionKey + n - 1), true
+		}
+	}
+	return 0, false
+}
+
+// normalized folds an upper-case key into lower case plus Shift, which is how
+// AppKit matches it, so equal shortcuts compare equal.
+func (a Accelerator) normalized() Accelerator {
+	r, size := utf8.DecodeRuneInString(a.Key)
+	if size == len(a.Key) && unicode.IsUpper(r) {
+		return Accelerator{Key: string(unicode.ToLower(r)), Modifiers: a.Modifiers | NSEventModifierFlagShift}
+	}
+	return a
+}
+
+// IsZero reports whether a has no key.
+func (a Accelerator) IsZero() bool {
+	return a.Key == ""
+}
+
+var acceleratorSymbols = map[rune]string{
+	' ':                     "Space",
+	nsTabCharacter:          "⇥",
+	nsCarriageReturn:        "↩",
+	nsEscapeCharacter:       "⎋",
+	nsBackspaceCharacter:    "⌫",
+	NSDeleteFunctionKey:     "⌦",
+	NSInsertFunctionKey:     "Insert",
+	NSHelpFunctionKey:       "Help",
+	NSUpArrowFunctionKey:    "↑",
+	NSDownArrowFunctionKey:  "↓",
+	NSLeftArrowFunctionKey:  "←",
+	NSRightArrowFunctionKey: "→",
+	NSHomeFunctionKey:       "↖",
+	NSEndFunctionKey:        "↘",
+	NSPageUpFunctionKey:     "⇞",
+	NSPageDownFunctionKey:   "⇟",
+}
+
+// String renders a the way macOS menus show it, such as "⌃⌥F5" or "⇧⌘S".
+func (a Accelerator) String() string {
+	if a.IsZero() {
+		return ""
+	}
+	a = a.normalized()
+	var b strings.Builder
+	for _, m := range []struct {
+		flag   uintptr
+		symbol string
+	}{
+		{NSEventModifierFlagControl, "⌃"},
+		{NSEventModifierFlagOption, "⌥"},
+		{NSEventModifierFlagShift, "⇧"},
+		{NSEventModifierFlagCommand, "⌘"},
+	} {
+		if a.Modifiers&m.flag != 0 {
+			b.WriteString(m.symbol)
+		}
+	}
+	r, size := utf8.DecodeRuneInString(a.Key)
+	switch {
+	case size != len(a.Key):
+		b.WriteString(a.Key)
+	case acceleratorSymbols[r] != "":
+		b.WriteString(acceleratorSymbols[r])
+	case r >= NSF1FunctionKey && r <= NSF35FunctionKey:
+		fmt.Fprintf(&b, "F%d", r-NSF1FunctionKey+1)
+	default:
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// accelerator returns the item's shortcut, from Accelerator if it is set and
+// from Key and ModifierFlags otherwise.
+func (item MenuItem) accelerator() (Accelerator, error) {
+	if item.Accelerator != "" {
+		return ParseAccelerator(item.Accelerator)
+	}
+	a := Accelerator{Key: item.Key}
+	for _, flag := range item.ModifierFlags {
+		a.Modifiers |= flag
+	}
+	return a, nil
+}
+
+// AcceleratorConflict is a shortcut shared by more than one item of a menu
+// tree. Items holds each item's path, such as "File > Save".
+type AcceleratorConflict struct {
+	Accelerator Accelerator
+	Items       []string
+}
+
+// ValidateAccelerators checks every accelerator in the menu tree and returns
+// an error for the first one that does not parse.
+func (m *Menu) ValidateAccelerators() error {
+	var err error
+	m.walk(nil, func(path []string, item MenuItem) {
+		if _, e := item.accelerator(); e != nil && err == nil {
+			err = fmt.Errorf("%s: %w", strings.Join(path, " > "), e)
+		}
+	})
+	return err
+}
+
+// Conflicts returns the shortcuts used by more than one item in the menu
+// tree, in the order they first appear. AppKit gives such a shortcut to
+// whichever item it finds first, so the others can never be triggered from
+// the keyboard. Items whose accelerator does not parse are skipped.
+func (m *Menu) Conflicts() []AcceleratorConflict {
+	var order []Accelerator
+	seen := make(map[Accelerator][]string)
+	m.walk(nil, func(path []string, item MenuItem) {
+		a, err := item.accelerator()
+		if err != nil || a.IsZero() {
+			return
+		}
+		a = a.normalized()
+		if _, ok := seen[a]; !ok {
+			order = append(order, a)
+		}
+		seen[a] = append(seen[a], strings.Join(path, " > "))
+	})
+
+	var conflicts []AcceleratorConflict
+	for _, a := range order {
+		if items := seen[a]; len(items) > 1 {
+			conflicts = append(conflicts, AcceleratorConflict{Accelerator: a, Items: items})
+		}
+	}
+	return conflicts
+}
+
+// walk calls f for every non-separator item in the tree, depth first, with
+// the titles leading to it.
+func (m *Menu) walk(path []string, f func(path []string, item MenuItem)) {
+	if m == nil {
+		return
+	}
+	for _, item := range m.Items {
+		if item.IsSeparator {
+			continue
+		}
+		itemPath := append(path[:len(path):len(path)], item.Title)
+		f(itemPath, item)
+		if item.Submenu != nil {
+			item.Submenu.walk(itemPath, f)
+		}
+	}
+}
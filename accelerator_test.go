@@ -0,0 +1,128 @@
+package darwin
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseAccelerator(t *testing.T) {
+	const (
+		cmd   = NSEventModifierFlagCommand
+		shift = NSEventModifierFlagShift
+		ctrl  = NSEventModifierFlagControl
+		opt   = NSEventModifierFlagOption
+	)
+	tests := []struct {
+		in   string
+		want Accelerator
+	}{
+		{"Cmd+Shift+S", Accelerator{"s", cmd | shift}},
+		{"Ctrl+Alt+F5", Accelerator{"\uF708", ctrl | opt}},
+		{"Cmd+Plus", Accelerator{"+", cmd}},
+		{"Cmd++", Accelerator{"+", cmd}},
+		{"Option+Delete", Accelerator{"\b", opt}},
+		{"cmd+forwarddelete", Accelerator{"\uF728", cmd}},
+		{"CmdOrCtrl+Q", Accelerator{"q", cmd}},
+		{"Command + Left", Accelerator{"\uF702", cmd}},
+		{"F12", Accelerator{"\uF70F", 0}},
+		{"Shift+F35", Accelerator{"\uF726", shift}},
+		{"Cmd+,", Accelerator{",", cmd}},
+		{"Cmd+Return", Accelerator{"\r", cmd}},
+	}
+	for _, tt := range tests {
+		got, err := ParseAccelerator(tt.in)
+		if err != nil {
+			t.Errorf("ParseAccelerator(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAccelerator(%q) = %+q, want %+q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseAcceleratorErrors(t *testing.T) {
+	for _, in := range []string{"", "Cmd+", "Hyper+S", "Cmd+Banana", "F0", "F36", "F05", "Cmd++S"} {
+		if _, err := ParseAccelerator(in); !errors.Is(err, ErrInvalidAccelerator) {
+			t.Errorf("ParseAccelerator(%q) error = %v, want ErrInvalidAccelerator", in, err)
+		}
+	}
+}
+
+func TestAcceleratorString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Cmd+Shift+S", "⇧⌘S"},
+		{"Ctrl+Alt+F5", "⌃⌥F5"},
+		{"Cmd+Plus", "⌘+"},
+		{"Option+Delete", "⌥⌫"},
+		{"Cmd+Up", "⌘↑"},
+		{"Cmd+Space", "⌘Space"},
+	}
+	for _, tt := range tests {
+		a, err := ParseAccelerator(tt.in)
+		if err != nil {
+			t.Fatalf("ParseAccelerator(%q): %v", tt.in, err)
+		}
+		if got := a.String(); got != tt.want {
+			t.Errorf("%q.String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	// An upper-case key equivalent implies Shift in AppKit.
+	if got := (Accelerator{Key: "S", Modifiers: NSEventModifierFlagCommand}).String(); got != "⇧⌘S" {
+		t.Errorf("upper-case key String() = %q, want %q", got, "⇧⌘S")
+	}
+}
+
+func TestMenuConflicts(t *testing.T) {
+	menu := &Menu{Items: []MenuItem{
+		{Title: "File", Submenu: &Menu{Items: []MenuItem{
+			{Title: "Save", Accelerator: "Cmd+S"},
+			{IsSeparator: true},
+			{Title: "Save As…", Accelerator: "Cmd+Shift+S"},
+			{Title: "Export", Submenu: &Menu{Items: []MenuItem{
+				{Title: "PNG", Key: "S", ModifierFlags: []uintptr{NSEventModifierFlagCommand}},
+			}}},
+		}}},
+		{Title: "Edit", Submenu: &Menu{Items: []MenuItem{
+			{Title: "Select All", Accelerator: "Cmd+A"},
+			{Title: "Sync", Key: "s", ModifierFlags: []uintptr{NSEventModifierFlagCommand}},
+		}}},
+	}}
+	want := []AcceleratorConflict{
+		{Accelerator{"s", NSEventModifierFlagCommand}, []string{"File > Save", "Edit > Sync"}},
+		{Accelerator{"s", NSEventModifierFlagCommand | NSEventModifierFlagShift}, []string{"File > Save As…", "File > Export > PNG"}},
+	}
+	if got := menu.Conflicts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conflicts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMenuValidateAccelerators(t *testing.T) {
+	menu := &Menu{Items: []MenuItem{
+		{Title: "View", Submenu: &Menu{Items: []MenuItem{
+			{Title: "Zoom In", Accelerator: "Cmd+Plus"},
+			{Title: "Zoom Out", Accelerator: "Cmd+Minsu"},
+		}}},
+	}}
+	err := menu.ValidateAccelerators()
+	if !errors.Is(err, ErrInvalidAccelerator) {
+		t.Fatalf("ValidateAccelerators() = %v, want ErrInvalidAccelerator", err)
+	}
+	if want := "View > Zoom Out: "; len(err.Error()) < len(want) || err.Error()[:len(want)] != want {
+		t.Errorf("ValidateAccelerators() = %q, want prefix %q", err, want)
+	}
+}
+
+func TestNilMenu(t *testing.T) {
+	var m *Menu
+	if err := m.ValidateAccelerators(); err != nil {
+		t.Errorf("ValidateAccelerators() = %v, want nil", err)
+	}
+	if c := m.Conflicts(); len(c) != 0 {
+		t.Errorf("Conflicts() = %v, want none", c)
+	}
+}
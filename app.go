@@ -1,12 +1,22 @@
+//go:build darwin
+
 package darwin
 
 import (
-	"fmt"
+	"context"
+	"sync/atomic"
 	"unsafe"
-	"visualizer/platform/cocoa"
 )
 
-func SetupApplication(appName string, delegate uintptr, menu cocoa.MenuBuilder) (Object, error) {
+// SetupApplication makes the application a regular Dock app with the given
+// delegate and a main menu in the ApplicationMenu layout.
+func SetupApplication(appName string, delegate uintptr, menu ApplicationMenu) (Object, error) {
+	return SetupApplicationMenu(appName, delegate, menu.Menu(appName))
+}
+
+// SetupApplicationMenu is SetupApplication with an arbitrary main menu.
+func SetupApplicationMenu(appName string, delegate uintptr, menu *Menu) (Object, error) {
+	checkMainThread("SetupApplicationMenu")
 	app, err := NSApp()
 	if err != nil {
 		return Object{}, err
@@ -15,58 +25,97 @@ func SetupApplication(appName string, delegate uintptr, menu cocoa.MenuBuilder)
 
 	Objc_sendMsg[uintptr](appPtr, Sel_setActivationPolicy, 0) // NSApplicationActivationPolicyRegular
 
-	mainMenu := Objc_alloc_init(Class_NSMenu)
-
-	buildTopLevelMenu := func(title string, items []cocoa.MenuItem) {
-		menuItem := Objc_alloc_init(Class_NSMenuItem)
-		Objc_sendMsg[uintptr](mainMenu, Sel_addItem, menuItem)
-		subMenu := Objc_alloc_init(Class_NSMenu)
-		Objc_sendMsg[uintptr](subMenu, Sel_setTitle, NSString_WithUTF8String(title).Ptr)
-		buildMenu(subMenu, items)
-		Objc_sendMsg[uintptr](menuItem, Sel_setSubmenu, subMenu)
-	}
-
-	appMenuItem := Objc_alloc_init(Class_NSMenuItem)
-	Objc_sendMsg[uintptr](mainMenu, Sel_addItem, appMenuItem)
-	appMenu := Objc_alloc_init(Class_NSMenu)
-	Objc_sendMsg[uintptr](appMenu, Sel_setTitle, NSString_WithUTF8String(appName).Ptr)
-
-	buildMenu(appMenu, menu.AppItems)
-	Objc_sendMsg[uintptr](appMenuItem, Sel_setSubmenu, appMenu)
-
-	buildTopLevelMenu("File", menu.FileItems)
-	buildTopLevelMenu("Edit", menu.EditItems)
-	buildTopLevelMenu("Window", menu.WindowItems)
-
+	// A menu with MenuRoleServices replaces this one.
 	servicesMenu := Objc_alloc_init(Class_NSMenu)
 	Objc_sendMsg[uintptr](appPtr, Sel_setServicesMenu, servicesMenu)
+	Objc_sendMsg[uintptr](servicesMenu, Sel_release)
 
-	Objc_sendMsg[uintptr](appPtr, Sel_setMainMenu, mainMenu)
+	if err := SetMainMenu(menu); err != nil {
+		return Object{}, err
+	}
 	Objc_sendMsg[uintptr](appPtr, Sel_setDelegate, delegate)
 
 	return app, nil
 }
 
-func buildMenu(nativeMenu uintptr, items []cocoa.MenuItem) {
+// SetMainMenu replaces the menu bar with menu. The Go actions of the previous
+// menu bar are released. A nil menu clears the menu bar. It returns an error
+// wrapping ErrInvalidAccelerator, and leaves the menu bar unchanged, if an
+// accelerator does not parse. It must be called on the main thread.
+func SetMainMenu(menu *Menu) error {
+	checkMainThread("SetMainMenu")
+	if err := menu.ValidateAccelerators(); err != nil {
+		return err
+	}
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	resetMenuActions()
+	if menu == nil {
+		Objc_sendMsg[uintptr](app, Sel_setMainMenu, uintptr(0))
+		return nil
+	}
+	mainMenuTags[0] = nextMenuAction
+	mainMenu := newNativeMenu(app, menu)
+	mainMenuTags[1] = nextMenuAction
+	Objc_sendMsg[uintptr](app, Sel_setMainMenu, mainMenu)
+	Objc_sendMsg[uintptr](mainMenu, Sel_release)
+	return nil
+}
+
+// newNativeMenu builds an NSMenu for menu and its submenus, registering any
+// with a role. A nil menu gives an empty NSMenu. The caller owns the returned
+// menu and must release it.
+func newNativeMenu(app uintptr, menu *Menu) uintptr {
+	if menu == nil {
+		menu = &Menu{}
+	}
+	nativeMenu := Objc_alloc_init(Class_NSMenu)
+	if menu.Title != "" {
+		Objc_sendMsg[uintptr](nativeMenu, Sel_setTitle, NSString_WithUTF8String(menu.Title).Ptr)
+	}
+	buildMenu(app, nativeMenu, menu.Items)
+	if skipMenuRoles {
+		return nativeMenu
+	}
+	switch menu.Role {
+	case MenuRoleWindows:
+		Objc_sendMsg[uintptr](app, Sel_setWindowsMenu, nativeMenu)
+	case MenuRoleHelp:
+		Objc_sendMsg[uintptr](app, Sel_setHelpMenu, nativeMenu)
+	case MenuRoleServices:
+		Objc_sendMsg[uintptr](app, Sel_setServicesMenu, nativeMenu)
+	}
+	return nativeMenu
+}
+
+func buildMenu(app, nativeMenu uintptr, items []MenuItem) {
 	for _, item := range items {
-		addMenuItem(nativeMenu, item)
+		addMenuItem(app, nativeMenu, item)
 	}
 }
 
-func addMenuItem(menu uintptr, item cocoa.MenuItem) {
+func addMenuItem(app, menu uintptr, item MenuItem) {
 	if item.IsSeparator {
 		sep := Objc_sendMsg[uintptr](Class_NSMenuItem, Sel_getUid("separatorItem"))
 		Objc_sendMsg[uintptr](menu, Sel_addItem, sep)
 		return
 	}
 
+	if item.Action == 0 && item.actionName != "" {
+		item.Action = Sel_getUid(item.actionName)
+	}
+	// Accelerators were validated before the build started.
+	accel, _ := item.accelerator()
 	titleStr := NSString_WithUTF8String(item.Title)
-	keyStr := NSString_WithUTF8String(item.Key)
+	keyStr := NSString_WithUTF8String(accel.Key)
 
 	var submenu uintptr
 	if item.Submenu != nil {
-		submenu = Objc_alloc_init(Class_NSMenu)
-		buildMenu(submenu, item.Submenu.AppItems)
+		sub := *item.Submenu
+		if sub.Title == "" {
+			// The menu bar shows the submenu's title, not the item's.
+			sub.Title = item.Title
+		}
+		submenu = newNativeMenu(app, &sub)
 	}
 
 	menuItem := Objc_sendMsg[uintptr](Class_NSMenuItem, Sel_alloc)
@@ -74,31 +123,132 @@ func addMenuItem(menu uintptr, item cocoa.MenuItem) {
 
 	if submenu != 0 {
 		Objc_sendMsg[uintptr](menuItem, Sel_setSubmenu, submenu)
+		Objc_sendMsg[uintptr](submenu, Sel_release)
 	}
-
-	mask := 0
-	for _, flag := range item.ModifierFlags {
-		mask |= int(flag)
+	bindMenuItem(menuItem, item)
+	if item.Handle != nil {
+		item.Handle.store(NSMenuItem{Object{unsafe.Pointer(menuItem)}})
 	}
-	if mask > 0 {
-		Objc_sendMsg[uintptr](menuItem, Sel_getUid("setKeyEquivalentModifierMask:"), uintptr(mask))
+
+	if accel.Modifiers != 0 {
+		Objc_sendMsg[uintptr](menuItem, Sel_setKeyEquivalentModifierMask, accel.Modifiers)
 	}
 
+	// The menu keeps the item, and with it the submenu, alive from here on.
 	Objc_sendMsg[uintptr](menu, Sel_addItem, menuItem)
+	Objc_sendMsg[uintptr](menuItem, Sel_release)
 }
 
 func NSApp() (Object, error) {
 	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
 	if app == 0 {
-		return Object{}, fmt.Errorf("shared NSApplication instance is nil")
+		return Object{}, ErrNoApplication
 	}
 	return Object{unsafe.Pointer(app)}, nil
 }
 
+// RunApplication runs the application's event loop until it is stopped or
+// the application terminates. Under Main it may be called from any goroutine;
+// otherwise it must be called on the main thread.
 func RunApplication(app Object) {
+	if mainBootstrapped.Load() && !isMainThread() {
+		runApplicationFromMain(app)
+		return
+	}
+	checkMainThread("RunApplication")
+	runEventLoop(app)
+}
+
+// stopRequested records a StopApplication that arrived while the event loop
+// was not running, so the next run returns at once instead of missing it. It
+// is only touched on the main thread.
+var stopRequested bool
+
+// runGeneration counts finished runs of the event loop. RunApplicationContext
+// uses it to drop a stop that fires after its own run has already returned.
+var runGeneration atomic.Uint64
+
+// runEventLoop sends run to the application unless a stop is already pending.
+func runEventLoop(app Object) {
+	defer runGeneration.Add(1)
+	if stopRequested {
+		stopRequested = false
+		return
+	}
 	Objc_sendMsg[uintptr](uintptr(app.Ptr), Sel_run)
 }
 
+// RunApplicationContext runs the event loop like RunApplication, and stops it
+// when ctx is done. It returns ctx.Err() if the loop stopped because of ctx,
+// and nil if it stopped for any other reason.
+func RunApplicationContext(ctx context.Context, app Object) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	gen := runGeneration.Load()
+	var stoppedByCtx atomic.Bool
+	stop := context.AfterFunc(ctx, func() {
+		MainThreadAsync(func() {
+			if runGeneration.Load() == gen {
+				stoppedByCtx.Store(true)
+				stopEventLoop()
+			}
+		})
+	})
+	defer stop()
+	RunApplication(app)
+	if stoppedByCtx.Load() {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// StopApplication makes RunApplication return after the event being handled,
+// leaving the application and its windows alive. It posts an empty event so
+// the loop notices the stop even when no input arrives. If the loop is not
+// running, the next RunApplication returns immediately. It is safe to call
+// from any goroutine.
+func StopApplication() {
+	MainThreadAsync(stopEventLoop)
+}
+
+func stopEventLoop() {
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	if !Objc_sendMsg[bool](app, Sel_isRunning) {
+		stopRequested = true
+		return
+	}
+	Objc_sendMsg[uintptr](app, Sel_stop, 0)
+	postWakeEvent()
+}
+
+// Terminate asks the application to quit, as the Quit menu item does. The
+// delegate's applicationWillTerminate runs, and then the process exits
+// without RunApplication returning. It is safe to call from any goroutine.
+func Terminate() {
+	MainThreadAsync(func() {
+		app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+		Objc_sendMsg[uintptr](app, Sel_terminate, 0)
+	})
+}
+
+// ActivateIgnoringOtherApps brings the application to the front, even if
+// another application is active. It always sends activateIgnoringOtherApps:,
+// which is deprecated on macOS 14 and later; use Activate there.
 func ActivateIgnoringOtherApps(app Object) {
+	checkMainThread("ActivateIgnoringOtherApps")
+	Objc_sendMsg[uintptr](uintptr(app.Ptr), Sel_activateIgnoringOtherApps, 1)
+}
+
+// Activate asks to bring the application to the front. On macOS 14 and later
+// it uses the cooperative activate, and the system may refuse when another
+// application is in use; earlier releases fall back to
+// activateIgnoringOtherApps:.
+func Activate(app Object) {
+	checkMainThread("Activate")
+	if IsOSAtLeast(MacOS14) && RespondsToSelector(uintptr(app.Ptr), Sel_activate) {
+		Objc_sendMsg[uintptr](uintptr(app.Ptr), Sel_activate)
+		return
+	}
 	Objc_sendMsg[uintptr](uintptr(app.Ptr), Sel_activateIgnoringOtherApps, 1)
 }
@@ -0,0 +1,113 @@
+package darwin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnavailable is returned when an API needs a newer macOS release than the
+// one running, or when the receiver does not respond to the selector. The
+// concrete value is an *UnavailableError.
+var ErrUnavailable = errors.New("darwin: API unavailable on this system")
+
+// OSVersion mirrors NSOperatingSystemVersion.
+type OSVersion struct {
+	Major, Minor, Patch int
+}
+
+// macOS releases that gate APIs used by this package.
+var (
+	MacOS10_15 = OSVersion{10, 15, 0}
+	MacOS11    = OSVersion{11, 0, 0}
+	MacOS12    = OSVersion{12, 0, 0}
+	MacOS13    = OSVersion{13, 0, 0}
+	MacOS14    = OSVersion{14, 0, 0}
+	MacOS15    = OSVersion{15, 0, 0}
+)
+
+// ParseOSVersion parses "major[.minor[.patch]]", as printed by sw_vers.
+func ParseOSVersion(s string) (OSVersion, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return OSVersion{}, fmt.Errorf("darwin: invalid OS version %q", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return OSVersion{}, fmt.Errorf("darwin: invalid OS version %q", s)
+		}
+		nums[i] = n
+	}
+	return OSVersion{nums[0], nums[1], nums[2]}, nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older than, equal to
+// or newer than o.
+func (v OSVersion) Compare(o OSVersion) int {
+	switch {
+	case v.Major != o.Major:
+		return cmpInt(v.Major, o.Major)
+	case v.Minor != o.Minor:
+		return cmpInt(v.Minor, o.Minor)
+	default:
+		return cmpInt(v.Patch, o.Patch)
+	}
+}
+
+// AtLeast reports whether v is the same release as o or newer.
+func (v OSVersion) AtLeast(o OSVersion) bool {
+	return v.Compare(o) >= 0
+}
+
+func (v OSVersion) IsZero() bool {
+	return v == OSVersion{}
+}
+
+func (v OSVersion) String() string {
+	if v.Patch == 0 {
+		return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func cmpInt(a, b int) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// UnavailableError describes why an API could not be used. Exactly one of
+// Required or Selector is set.
+type UnavailableError struct {
+	Op       string
+	Required OSVersion
+	Running  OSVersion
+	Selector string
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Selector != "" {
+		return fmt.Sprintf("darwin: %s unavailable: receiver does not respond to %s", e.Op, e.Selector)
+	}
+	return fmt.Sprintf("darwin: %s requires macOS %s, running %s", e.Op, e.Required, e.Running)
+}
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUnavailable
+}
+
+// checkOSVersion is the comparison behind RequireOS, kept separate so it can be
+// exercised without a running system.
+func checkOSVersion(op string, running, required OSVersion) error {
+	if running.AtLeast(required) {
+		return nil
+	}
+	return &UnavailableError{Op: op, Required: required, Running: running}
+}
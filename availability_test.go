@@ -0,0 +1,75 @@
+package darwin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOSVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b OSVersion
+		want int
+	}{
+		{OSVersion{14, 0, 0}, OSVersion{14, 0, 0}, 0},
+		{OSVersion{13, 6, 1}, OSVersion{14, 0, 0}, -1},
+		{OSVersion{14, 1, 0}, OSVersion{14, 0, 9}, 1},
+		{OSVersion{10, 15, 7}, OSVersion{11, 0, 0}, -1},
+		{OSVersion{12, 7, 2}, OSVersion{12, 7, 1}, 1},
+		{OSVersion{}, MacOS10_15, -1},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b); got != tt.want {
+			t.Errorf("%v.Compare(%v) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := tt.b.Compare(tt.a); got != -tt.want {
+			t.Errorf("%v.Compare(%v) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+		if got := tt.a.AtLeast(tt.b); got != (tt.want >= 0) {
+			t.Errorf("%v.AtLeast(%v) = %v", tt.a, tt.b, got)
+		}
+	}
+}
+
+func TestParseOSVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    OSVersion
+		wantErr bool
+	}{
+		{"14", OSVersion{14, 0, 0}, false},
+		{"10.15", OSVersion{10, 15, 0}, false},
+		{"13.6.1", OSVersion{13, 6, 1}, false},
+		{" 15.0\n", OSVersion{15, 0, 0}, false},
+		{"", OSVersion{}, true},
+		{"14.x", OSVersion{}, true},
+		{"1.2.3.4", OSVersion{}, true},
+		{"-1.0", OSVersion{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseOSVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOSVersion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseOSVersion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckOSVersion(t *testing.T) {
+	if err := checkOSVersion("op", OSVersion{14, 2, 0}, MacOS14); err != nil {
+		t.Fatalf("newer release rejected: %v", err)
+	}
+	err := checkOSVersion("NSApplication.activate", OSVersion{13, 6, 0}, MacOS14)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("older release: got %v, want ErrUnavailable", err)
+	}
+	var ue *UnavailableError
+	if !errors.As(err, &ue) || ue.Required != MacOS14 || ue.Running != (OSVersion{13, 6, 0}) {
+		t.Fatalf("unexpected error detail: %#v", err)
+	}
+	if want := "darwin: NSApplication.activate requires macOS 14.0, running 13.6"; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
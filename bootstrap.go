@@ -0,0 +1,74 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"sync/atomic"
+)
+
+// mainBootstrapped is set while Main owns the main thread.
+var mainBootstrapped atomic.Bool
+
+// State handed between the user goroutine and the loop in Main. It is only
+// touched on the main thread.
+var (
+	pendingAppRun *appRunRequest
+	mainExiting   bool
+)
+
+type appRunRequest struct {
+	app  Object
+	done chan struct{}
+}
+
+// Main takes over the process main thread and runs run on a separate
+// goroutine. It must be called from main.main, which the package's init
+// keeps locked to the first OS thread. Main returns once run returns.
+//
+// While run executes, the main thread services MainThread, MainThreadAsync
+// and the other dispatch functions, even before the application's event loop
+// has started. RunApplication called from run hands the event loop to the
+// main thread and blocks until it exits.
+func Main(run func()) {
+	if err := Initialize(); err != nil {
+		panic(err)
+	}
+	if !isMainThread() {
+		panic("darwin: Main must be called from the main goroutine")
+	}
+	mainBootstrapped.Store(true)
+	defer mainBootstrapped.Store(false)
+
+	go func() {
+		defer MainThreadAsync(func() {
+			mainExiting = true
+			cfRunLoopStop(mainRunLoop)
+		})
+		run()
+	}()
+
+	for {
+		cfRunLoopRun()
+		if req := pendingAppRun; req != nil {
+			pendingAppRun = nil
+			runEventLoop(req.app)
+			close(req.done)
+			continue
+		}
+		if mainExiting {
+			return
+		}
+	}
+}
+
+// runApplicationFromMain asks the loop in Main to run the event loop at the top
+// level of the main thread, rather than nested inside a dispatched call, and
+// waits for it to return.
+func runApplicationFromMain(app Object) {
+	req := &appRunRequest{app: app, done: make(chan struct{})}
+	MainThreadAsync(func() {
+		pendingAppRun = req
+		cfRunLoopStop(mainRunLoop)
+	})
+	<-req.done
+}
@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -8,24 +10,6 @@ import (
 	"github.com/ebitengine/purego"
 )
 
-// WindowDelegate is a Go interface that our native callbacks will call.
-type WindowDelegate interface {
-	WindowShouldClose()
-	WindowDidResize(resizedWindow NSWindow)
-	KeyDown(event NSEvent)
-	KeyUp(event NSEvent)
-	MouseDown(event NSEvent)
-	MouseUp(event NSEvent)
-	MouseMoved(event NSEvent)
-	MouseDragged(event NSEvent)
-	ScrollWheel(event NSEvent)
-	FlagsChanged(event NSEvent)
-	MagnifyGesture(event NSEvent)
-	RotateGesture(event NSEvent)
-	SwipeGesture(event NSEvent)
-	FilesDropped(files []string)
-}
-
 var appDelegateCallback func()
 var appTerminationCallback func()
 
@@ -38,9 +22,12 @@ func SetAppTerminationCallback(f func()) {
 }
 
 func applicationDidFinishLaunching(id, sel, notification uintptr) {
-	if appDelegateCallback != nil {
-		appDelegateCallback()
-	}
+	runCallback(func() {
+		appLaunched.Store(true)
+		if appDelegateCallback != nil {
+			appDelegateCallback()
+		}
+	})
 }
 
 var shouldTerminateAfterLastWindowClosed = true
@@ -50,9 +37,13 @@ func applicationShouldTerminateAfterLastWindowClosed(id, sel, sender uintptr) bo
 }
 
 func applicationWillTerminate(id, sel, notification uintptr) {
-	if appTerminationCallback != nil {
-		appTerminationCallback()
-	}
+	runCallback(func() {
+		stopMainLoop()
+		RestoreGlobalState()
+		if appTerminationCallback != nil {
+			appTerminationCallback()
+		}
+	})
 }
 
 func getGoWindowDelegate(viewInstance uintptr) WindowDelegate {
@@ -79,133 +70,178 @@ func acceptsFirstResponder(id, sel uintptr) bool {
 }
 
 func viewDidMoveToWindow(id, sel uintptr) {
-	log.Println("[NATIVE] viewDidMoveToWindow called")
-	window := Objc_sendMsg[uintptr](id, Sel_window)
-	if window != 0 {
-		log.Println("[NATIVE] View has a window, attempting to make first responder.")
-		// This makes our view the target for keyboard and other events.
-		Objc_sendMsg[bool](window, Sel_makeFirstResponder, id)
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] viewDidMoveToWindow called")
+		window := Objc_sendMsg[uintptr](id, Sel_window)
+		if window != 0 {
+			log.Println("[NATIVE] View has a window, attempting to make first responder.")
+			// This makes our view the target for keyboard and other events.
+			Objc_sendMsg[bool](window, Sel_makeFirstResponder, id)
+		}
+	})
 }
 
 func updateTrackingAreas(id, sel uintptr) {
-	// log.Println("[NATIVE] updateTrackingAreas called")
-	class := Objc_sendMsg[uintptr](id, Sel_class)
-	superClass, _, _ := purego.SyscallN(class_getSuperclass_ptr, class)
-	super := objc_super{
-		Receiver:   id,
-		SuperClass: superClass,
-	}
-	var super_updateTrackingAreas func(*objc_super, Selector)
-	purego.RegisterLibFunc(&super_updateTrackingAreas, libobjc, "objc_msgSendSuper")
-	super_updateTrackingAreas(&super, Sel_updateTrackingAreas)
-
-	trackingAreas := Objc_sendMsg[uintptr](id, Sel_getUid("trackingAreas"))
-	count := Objc_sendMsg[uintptr](trackingAreas, Sel_count)
-	for i := uintptr(0); i < count; i++ {
-		area := Objc_sendMsg[uintptr](trackingAreas, Sel_objectAtIndex, i)
-		Objc_sendMsg[uintptr](id, Sel_getUid("removeTrackingArea:"), area)
-	}
+	runCallback(func() {
+		// log.Println("[NATIVE] updateTrackingAreas called")
+		class := Objc_sendMsg[uintptr](id, Sel_class)
+		superClass, _, _ := purego.SyscallN(class_getSuperclass_ptr, class)
+		super := objc_super{
+			Receiver:   id,
+			SuperClass: superClass,
+		}
+		var super_updateTrackingAreas func(*objc_super, Selector)
+		purego.RegisterLibFunc(&super_updateTrackingAreas, libobjc, "objc_msgSendSuper")
+		super_updateTrackingAreas(&super, Sel_updateTrackingAreas)
+
+		trackingAreas := Objc_sendMsg[uintptr](id, Sel_getUid("trackingAreas"))
+		count := Objc_sendMsg[uintptr](trackingAreas, Sel_count)
+		for i := uintptr(0); i < count; i++ {
+			area := Objc_sendMsg[uintptr](trackingAreas, Sel_objectAtIndex, i)
+			Objc_sendMsg[uintptr](id, Sel_getUid("removeTrackingArea:"), area)
+		}
 
-	frame := Objc_sendMsg[NSRect](id, Sel_frame)
-	options := NSTrackingMouseMoved | NSTrackingActiveInKeyWindow | NSTrackingMouseEnteredAndExited
+		frame := Objc_sendMsg[NSRect](id, Sel_frame)
+		options := NSTrackingMouseMoved | NSTrackingActiveInKeyWindow | NSTrackingMouseEnteredAndExited
 
-	trackingAreaAlloc := Objc_sendMsg[uintptr](Class_NSTrackingArea, Sel_alloc)
-	var initWithRectOptions func(uintptr, Selector, NSRect, uintptr, uintptr, uintptr) uintptr
-	purego.RegisterLibFunc(&initWithRectOptions, libobjc, "objc_msgSend")
-	trackingArea := initWithRectOptions(trackingAreaAlloc, Sel_initWithRectOptionsOwnerUserInfo, frame, uintptr(options), id, 0)
+		trackingAreaAlloc := Objc_sendMsg[uintptr](Class_NSTrackingArea, Sel_alloc)
+		var initWithRectOptions func(uintptr, Selector, NSRect, uintptr, uintptr, uintptr) uintptr
+		purego.RegisterLibFunc(&initWithRectOptions, libobjc, "objc_msgSend")
+		trackingArea := initWithRectOptions(trackingAreaAlloc, Sel_initWithRectOptionsOwnerUserInfo, frame, uintptr(options), id, 0)
 
-	if trackingArea != 0 {
-		Objc_sendMsg[uintptr](id, Sel_addTrackingArea, trackingArea)
-		Objc_sendMsg[uintptr](trackingArea, Sel_release)
-	}
+		if trackingArea != 0 {
+			Objc_sendMsg[uintptr](id, Sel_addTrackingArea, trackingArea)
+			Objc_sendMsg[uintptr](trackingArea, Sel_release)
+		}
+	})
 }
 
 func keyDown(id, sel, event uintptr) {
-	log.Println("[NATIVE] keyDown called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.KeyDown(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] keyDown called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.KeyDown(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func keyUp(id, sel, event uintptr) {
-	log.Println("[NATIVE] keyUp called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.KeyUp(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] keyUp called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.KeyUp(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func mouseDown(id, sel, event uintptr) {
-	log.Println("[NATIVE] mouseDown called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.MouseDown(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] mouseDown called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseDown(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
+}
+
+// Right clicks reach the delegate's MouseDown and MouseUp, with
+// EventButtonNumber 1, so it can open a context menu.
+func rightMouseDown(id, sel, event uintptr) {
+	runCallback(func() {
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseDown(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
+}
+
+func rightMouseUp(id, sel, event uintptr) {
+	runCallback(func() {
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseUp(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func mouseUp(id, sel, event uintptr) {
-	log.Println("[NATIVE] mouseUp called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.MouseUp(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] mouseUp called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseUp(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func mouseMoved(id, sel, event uintptr) {
-	// This can be very noisy, so logging is commented out.
-	// log.Println("[NATIVE] mouseMoved called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.MouseMoved(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		// This can be very noisy, so logging is commented out.
+		// log.Println("[NATIVE] mouseMoved called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseMoved(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func mouseDragged(id, sel, event uintptr) {
-	// log.Println("[NATIVE] mouseDragged called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.MouseDragged(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		// log.Println("[NATIVE] mouseDragged called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.MouseDragged(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func scrollWheel(id, sel, event uintptr) {
-	log.Println("[NATIVE] scrollWheel called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.ScrollWheel(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] scrollWheel called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.ScrollWheel(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
 func flagsChanged(id, sel, event uintptr) {
-	log.Println("[NATIVE] flagsChanged called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.FlagsChanged(NSEvent{Object{unsafe.Pointer(event)}})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] flagsChanged called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.FlagsChanged(NSEvent{Object{unsafe.Pointer(event)}})
+		}
+	})
 }
 
-func draggingEntered(id, sel, sender uintptr) uintptr {
-	log.Println("[NATIVE] draggingEntered called")
-	const NSDragOperationCopy = 1
-	return NSDragOperationCopy
+func draggingEntered(id, sel, sender uintptr) (operation uintptr) {
+	runCallback(func() {
+		log.Println("[NATIVE] draggingEntered called")
+		const NSDragOperationCopy = 1
+		operation = NSDragOperationCopy
+	})
+	return operation
 }
 
-func performDragOperation(id, sel, sender uintptr) bool {
+func performDragOperation(id, sel, sender uintptr) (accepted bool) {
 	log.Println("[NATIVE] performDragOperation called")
-	pb := Objc_sendMsg[uintptr](sender, Sel_draggingPasteboard)
-	if pb == 0 {
-		return false
-	}
+	runCallback(func() {
+		pb := Objc_sendMsg[uintptr](sender, Sel_draggingPasteboard)
+		if pb == 0 {
+			return
+		}
 
-	paths := EventFilePathsFromPasteboard(pb)
-	if len(paths) > 0 {
-		if delegate := getGoWindowDelegate(id); delegate != nil {
-			delegate.FilesDropped(paths)
-			return true
+		paths := EventFilePathsFromPasteboard(pb)
+		if len(paths) > 0 {
+			if delegate := getGoWindowDelegate(id); delegate != nil {
+				delegate.FilesDropped(paths)
+				accepted = true
+			}
 		}
-	}
-	return false
+	})
+	return accepted
 }
 
 func windowShouldClose(id, sel, window uintptr) bool {
 	log.Println("[NATIVE] windowShouldClose called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		delegate.WindowShouldClose()
-	}
+	runCallback(func() {
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			delegate.WindowShouldClose()
+		}
+	})
 	var goPtr uintptr
 	object_getInstanceVariable(id, "goWindowPtr", unsafe.Pointer(&goPtr))
 	if goPtr != 0 {
@@ -215,11 +251,13 @@ func windowShouldClose(id, sel, window uintptr) bool {
 }
 
 func windowDidResize(id, sel, notification uintptr) {
-	log.Println("[NATIVE] windowDidResize called")
-	if delegate := getGoWindowDelegate(id); delegate != nil {
-		windowObject := Object{unsafe.Pointer(Objc_sendMsg[uintptr](notification, Sel_object))}
-		delegate.WindowDidResize(NSWindow{windowObject})
-	}
+	runCallback(func() {
+		log.Println("[NATIVE] windowDidResize called")
+		if delegate := getGoWindowDelegate(id); delegate != nil {
+			windowObject := Object{unsafe.Pointer(Objc_sendMsg[uintptr](notification, Sel_object))}
+			delegate.WindowDidResize(NSWindow{windowObject})
+		}
+	})
 }
 
 func setupCustomOpenGLViewClass() {
@@ -250,6 +288,8 @@ func setupCustomOpenGLViewClass() {
 	addMethod(Sel_keyUp, keyUp, "v@:@")
 	addMethod(Sel_mouseDown, mouseDown, "v@:@")
 	addMethod(Sel_mouseUp, mouseUp, "v@:@")
+	addMethod(Sel_rightMouseDown, rightMouseDown, "v@:@")
+	addMethod(Sel_rightMouseUp, rightMouseUp, "v@:@")
 	addMethod(Sel_mouseMoved, mouseMoved, "v@:@")
 	addMethod(Sel_mouseDragged, mouseDragged, "v@:@")
 	addMethod(Sel_scrollWheel, scrollWheel, "v@:@")
@@ -284,5 +324,6 @@ func setupAppDelegateClass() {
 	addMethod(Sel_applicationDidFinishLaunching, applicationDidFinishLaunching, "v@:@")
 	addMethod(Sel_applicationShouldTerminateAfterLastWindowClosed, applicationShouldTerminateAfterLastWindowClosed, "B@:@")
 	addMethod(Sel_applicationWillTerminate, applicationWillTerminate, "v@:@")
+	addMethod(Sel_applicationDockMenu, applicationDockMenu, "@@:@")
 	objc_registerClassPair(class)
 }
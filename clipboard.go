@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -13,7 +15,7 @@ func NSString_WithUTF8String(s string) NSString {
 	class := Objc_sendMsg[uintptr](Class_NSString, Sel_alloc)
 	cString := NewCString(s)
 	nsStringPtr := Objc_sendMsg[uintptr](class, Sel_initWithUTF8String, uintptr(unsafe.Pointer(cString)))
-	
+
 	nsStringObj := Object{unsafe.Pointer(nsStringPtr)}
 	nsStringObj.Autorelease()
 
@@ -31,10 +33,10 @@ func (s NSString) String() string {
 func GetClipboardString() (string, error) {
 	pool := NewAutoreleasePool()
 	defer pool.Drain()
-	
+
 	pb := Objc_sendMsg[uintptr](Class_NSPasteboard, Sel_generalPasteboard)
 	if pb == 0 {
-		return "", fmt.Errorf("failed to get general pasteboard")
+		return "", ErrNoPasteboard
 	}
 
 	typeString := NSString_WithUTF8String("public.utf8-plain-text")
@@ -42,7 +44,7 @@ func GetClipboardString() (string, error) {
 	if ret == 0 {
 		return "", nil
 	}
-	
+
 	nsString := NSString{Object{unsafe.Pointer(ret)}}
 	return nsString.String(), nil
 }
@@ -53,7 +55,7 @@ func SetClipboardString(value string) error {
 
 	pb := Objc_sendMsg[uintptr](Class_NSPasteboard, Sel_generalPasteboard)
 	if pb == 0 {
-		return fmt.Errorf("failed to get general pasteboard")
+		return ErrNoPasteboard
 	}
 
 	// The setString:forType: method clears previous contents and then sets the new value.
@@ -63,7 +65,7 @@ func SetClipboardString(value string) error {
 
 	ok := Objc_sendMsg[bool](pb, Sel_setStringForType, uintptr(nsValue.Ptr), uintptr(typeString.Ptr))
 	if !ok {
-		return fmt.Errorf("setString:forType: returned false, indicating failure")
+		return fmt.Errorf("%w: setString:forType: returned NO", ErrPasteboardWrite)
 	}
 
 	return nil
@@ -0,0 +1,72 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"github.com/ebitengine/purego"
+)
+
+// PopUpContextMenu shows menu as a context menu at location, in view's
+// coordinates, and returns once it closes. It reports the item that was
+// picked, if any, after that item's action has run: selector actions go along
+// the responder chain as from the menu bar, and Go actions run as usual.
+// Menu roles and accelerators that do not parse are ignored, and a nil menu
+// shows nothing. It must be called on the main thread, typically from
+// MouseDown for a right click.
+func PopUpContextMenu(view Object, menu *Menu, location NSPoint) (MenuItem, bool) {
+	checkMainThread("PopUpContextMenu")
+	if menu == nil {
+		return MenuItem{}, false
+	}
+	pool := NewAutoreleasePool()
+	defer pool.Drain()
+
+	viewPtr := uintptr(view.Ptr)
+	window := Objc_sendMsg[uintptr](viewPtr, Sel_window)
+	if window == 0 {
+		return MenuItem{}, false
+	}
+
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	// An action may build other menus while this one is open, so only the
+	// tags taken by this build are dropped.
+	first := nextMenuAction
+	contextMenu := newContextMenu(app, menu)
+	tags := [2]int{first, nextMenuAction}
+	defer func() {
+		deleteMenuEntries(tags)
+		Objc_sendMsg[uintptr](contextMenu, Sel_release)
+	}()
+
+	var picked *menuEntry
+	prevPicked := menuPicked
+	menuPicked = func(e *menuEntry) { picked = e }
+	defer func() { menuPicked = prevPicked }()
+
+	var convertPoint func(uintptr, Selector, NSPoint, uintptr) NSPoint
+	purego.RegisterLibFunc(&convertPoint, libobjc, "objc_msgSend")
+	inWindow := convertPoint(viewPtr, Sel_convertPointToView, location, 0)
+
+	var mouseEventWithType func(uintptr, Selector, uint64, NSPoint, uint64, float64, int64, uintptr, int64, int64, float32) uintptr
+	purego.RegisterLibFunc(&mouseEventWithType, libobjc, "objc_msgSend")
+	windowNumber := int64(Objc_sendMsg[uintptr](window, Sel_windowNumber))
+	event := mouseEventWithType(Class_NSEvent, Sel_mouseEventWithType,
+		NSEventTypeRightMouseDown, inWindow, 0, 0, windowNumber, 0, 0, 1, 1)
+	if event == 0 {
+		return MenuItem{}, false
+	}
+
+	Objc_sendMsg[uintptr](Class_NSMenu, Sel_popUpContextMenuWithEventForView, contextMenu, event, viewPtr)
+	if picked == nil {
+		return MenuItem{}, false
+	}
+	return picked.item, true
+}
+
+// newContextMenu builds menu with every item bound to the Go target, so the
+// pick is seen whatever kind of action the item has.
+func newContextMenu(app uintptr, menu *Menu) uintptr {
+	routeMenuSelectors, skipMenuRoles = true, true
+	defer func() { routeMenuSelectors, skipMenuRoles = false, false }()
+	return newNativeMenu(app, menu)
+}
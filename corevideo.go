@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -6,13 +8,6 @@ import (
 	"github.com/ebitengine/purego"
 )
 
-type CVDisplayLinkRef uintptr
-type CGDirectDisplayID uint32
-
-const (
-	KCVReturnSuccess = 0
-)
-
 var (
 	_CVDisplayLinkCreateWithCGDisplay,
 	_CVDisplayLinkSetOutputCallback,
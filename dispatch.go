@@ -0,0 +1,204 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	_dispatch_queue_create,
+	_dispatch_queue_attr_make_with_qos_class,
+	_dispatch_get_global_queue,
+	_dispatch_async_f,
+	_dispatch_after_f,
+	_dispatch_time,
+	_dispatch_release,
+	_dispatch_group_create,
+	_dispatch_group_async_f,
+	_dispatch_group_notify_f,
+	_dispatch_group_enter,
+	_dispatch_group_leave,
+	_dispatch_group_wait,
+	_dispatch_semaphore_create,
+	_dispatch_semaphore_signal,
+	_dispatch_semaphore_wait uintptr
+
+	dispatchMainQueue           DispatchQueue
+	dispatchQueueAttrConcurrent uintptr
+)
+
+const (
+	dispatchTimeNow     = 0
+	dispatchTimeForever = ^uint64(0)
+)
+
+var (
+	dispatchCalloutOnce sync.Once
+	dispatchCallout     uintptr
+)
+
+// dispatchContext stores f in the Go pointer registry and returns the context
+// and function pointer for one of the *_f calls. The callout frees the entry,
+// so each context must be handed to libdispatch exactly once.
+func dispatchContext(f func()) (ctx, fn uintptr) {
+	dispatchCalloutOnce.Do(func() {
+		dispatchCallout = purego.NewCallback(dispatchFunctionFired)
+	})
+	return StoreGoPointer(f), dispatchCallout
+}
+
+// dispatchFunctionFired is the dispatch_function_t shared by every call. ctx
+// is the Go pointer ID of the func.
+func dispatchFunctionFired(ctx uintptr) {
+	f, ok := GetGoPointer(ctx).(func())
+	FreeGoPointer(ctx)
+	if !ok {
+		return
+	}
+	if p := runRecovered(f); p != nil {
+		handleMainThreadPanic(p)
+	}
+}
+
+// dispatchTimeout converts a timeout to a dispatch_time_t. A negative timeout
+// means wait forever.
+func dispatchTimeout(timeout time.Duration) uint64 {
+	if timeout < 0 {
+		return dispatchTimeForever
+	}
+	when, _, _ := purego.SyscallN(_dispatch_time, dispatchTimeNow, uintptr(timeout.Nanoseconds()))
+	return uint64(when)
+}
+
+// MainQueue returns the serial queue whose blocks run on the main thread. The
+// main run loop drains it in the common modes, so it is serviced under
+// RunApplication, Main and WaitEvents.
+func MainQueue() DispatchQueue {
+	return dispatchMainQueue
+}
+
+// GlobalQueue returns the system's concurrent queue for qos. It must not be
+// released.
+func GlobalQueue(qos QoSClass) DispatchQueue {
+	q, _, _ := purego.SyscallN(_dispatch_get_global_queue, uintptr(qos), 0)
+	return DispatchQueue(q)
+}
+
+// NewSerialQueue creates a queue that runs one function at a time, in
+// submission order, at the given QoS class. Release it when done.
+func NewSerialQueue(label string, qos QoSClass) DispatchQueue {
+	return newDispatchQueue(label, 0, qos)
+}
+
+// NewConcurrentQueue creates a queue that may run its functions in parallel,
+// at the given QoS class. Release it when done.
+func NewConcurrentQueue(label string, qos QoSClass) DispatchQueue {
+	return newDispatchQueue(label, dispatchQueueAttrConcurrent, qos)
+}
+
+func newDispatchQueue(label string, attr uintptr, qos QoSClass) DispatchQueue {
+	if qos != QoSUnspecified {
+		attr, _, _ = purego.SyscallN(_dispatch_queue_attr_make_with_qos_class, attr, uintptr(qos), 0)
+	}
+	// dispatch_queue_create copies the label.
+	q, _, _ := purego.SyscallN(_dispatch_queue_create, uintptr(unsafe.Pointer(NewCString(label))), attr)
+	return DispatchQueue(q)
+}
+
+// Async submits f to the queue and returns immediately.
+func (q DispatchQueue) Async(f func()) {
+	ctx, fn := dispatchContext(f)
+	purego.SyscallN(_dispatch_async_f, uintptr(q), ctx, fn)
+}
+
+// After submits f to the queue once d has elapsed.
+func (q DispatchQueue) After(d time.Duration, f func()) {
+	if d < 0 {
+		d = 0
+	}
+	ctx, fn := dispatchContext(f)
+	purego.SyscallN(_dispatch_after_f, uintptr(dispatchTimeout(d)), uintptr(q), ctx, fn)
+}
+
+// Release drops the caller's reference to a queue from NewSerialQueue or
+// NewConcurrentQueue. Functions already submitted still run.
+func (q DispatchQueue) Release() {
+	purego.SyscallN(_dispatch_release, uintptr(q))
+}
+
+// NewDispatchGroup creates a group for waiting on a set of functions. Release
+// it when done.
+func NewDispatchGroup() DispatchGroup {
+	g, _, _ := purego.SyscallN(_dispatch_group_create)
+	return DispatchGroup(g)
+}
+
+// Async submits f to q as part of the group.
+func (g DispatchGroup) Async(q DispatchQueue, f func()) {
+	ctx, fn := dispatchContext(f)
+	purego.SyscallN(_dispatch_group_async_f, uintptr(g), uintptr(q), ctx, fn)
+}
+
+// Notify submits f to q once every function in the group has finished. If
+// the group is already empty, f is submitted at once.
+func (g DispatchGroup) Notify(q DispatchQueue, f func()) {
+	ctx, fn := dispatchContext(f)
+	purego.SyscallN(_dispatch_group_notify_f, uintptr(g), uintptr(q), ctx, fn)
+}
+
+// Enter marks work started outside Async as part of the group. Each Enter
+// must be balanced by a Leave.
+func (g DispatchGroup) Enter() {
+	purego.SyscallN(_dispatch_group_enter, uintptr(g))
+}
+
+// Leave marks work begun with Enter as finished.
+func (g DispatchGroup) Leave() {
+	purego.SyscallN(_dispatch_group_leave, uintptr(g))
+}
+
+// Wait blocks until every function in the group has finished or timeout
+// elapses, and reports whether the group finished. A negative timeout waits
+// without a deadline.
+func (g DispatchGroup) Wait(timeout time.Duration) bool {
+	ret, _, _ := purego.SyscallN(_dispatch_group_wait, uintptr(g), uintptr(dispatchTimeout(timeout)))
+	return ret == 0
+}
+
+// Release drops the caller's reference to the group.
+func (g DispatchGroup) Release() {
+	purego.SyscallN(_dispatch_release, uintptr(g))
+}
+
+// NewDispatchSemaphore creates a counting semaphore with the given initial
+// value, which must not be negative. Release it when done, after its value
+// is back to at least the initial value.
+func NewDispatchSemaphore(value int) DispatchSemaphore {
+	s, _, _ := purego.SyscallN(_dispatch_semaphore_create, uintptr(value))
+	return DispatchSemaphore(s)
+}
+
+// Signal increments the semaphore, waking one waiter if there is one. It
+// reports whether a waiter was woken.
+func (s DispatchSemaphore) Signal() bool {
+	ret, _, _ := purego.SyscallN(_dispatch_semaphore_signal, uintptr(s))
+	return ret != 0
+}
+
+// Wait decrements the semaphore, blocking while it is zero until it is
+// signaled or timeout elapses. It reports whether the semaphore was acquired.
+// A negative timeout waits without a deadline.
+func (s DispatchSemaphore) Wait(timeout time.Duration) bool {
+	ret, _, _ := purego.SyscallN(_dispatch_semaphore_wait, uintptr(s), uintptr(dispatchTimeout(timeout)))
+	return ret == 0
+}
+
+// Release drops the caller's reference to the semaphore.
+func (s DispatchSemaphore) Release() {
+	purego.SyscallN(_dispatch_release, uintptr(s))
+}
@@ -0,0 +1,170 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"image"
+
+	"github.com/ebitengine/purego"
+)
+
+// The Dock tile shows the application icon until it is given a content view.
+// Once a custom image or progress is set, dockIconView draws the icon and
+// dockOverlayView, a subview, draws the progress bar over it. Both are only
+// touched on the main thread.
+var (
+	dockIconView    uintptr
+	dockOverlayView uintptr
+	dockCustomIcon  bool
+	dockProgress    = -1.0
+)
+
+const nsImageScaleProportionallyUpOrDown = 3
+
+// dockOverlaySize is the resolution of the progress overlay; the tile scales
+// it to the Dock's current size.
+const dockOverlaySize = 256
+
+func dockTile() uintptr {
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	return Objc_sendMsg[uintptr](app, Sel_dockTile)
+}
+
+// SetDockBadge shows label in a badge on the Dock tile, such as a count of
+// unread items. An empty label removes the badge.
+func SetDockBadge(label string) {
+	checkMainThread("SetDockBadge")
+	var nsLabel uintptr
+	if label != "" {
+		nsLabel = uintptr(NSString_WithUTF8String(label).Ptr)
+	}
+	Objc_sendMsg[uintptr](dockTile(), Sel_setBadgeLabel, nsLabel)
+}
+
+// SetDockTileImage draws img in the Dock tile in place of the application
+// icon, while the application runs. A nil img goes back to the icon.
+func SetDockTileImage(img image.Image) error {
+	checkMainThread("SetDockTileImage")
+	dockCustomIcon = img != nil
+	return updateDockTile(img)
+}
+
+// SetDockProgress draws a progress bar across the bottom of the Dock tile,
+// filled to progress, which is clamped to [0, 1]. A negative progress removes
+// the bar.
+func SetDockProgress(progress float64) error {
+	checkMainThread("SetDockProgress")
+	dockProgress = clampDockProgress(progress)
+	return updateDockTile(nil)
+}
+
+// updateDockTile brings the tile's views in line with the current state. A
+// non-nil icon replaces the image of the icon view.
+func updateDockTile(icon image.Image) error {
+	tile := dockTile()
+	if !dockCustomIcon && dockProgress < 0 {
+		Objc_sendMsg[uintptr](tile, Sel_setContentView, uintptr(0))
+		releaseDockViews()
+		Objc_sendMsg[uintptr](tile, Sel_display)
+		return nil
+	}
+
+	pool := NewAutoreleasePool()
+	defer pool.Drain()
+
+	if dockIconView == 0 {
+		var size func(uintptr, Selector) NSSize
+		purego.RegisterLibFunc(&size, libobjc, "objc_msgSend")
+		frame := NSRect{Size: size(tile, Sel_size)}
+		dockIconView = newDockImageView(frame)
+		dockOverlayView = newDockImageView(frame)
+		Objc_sendMsg[uintptr](dockIconView, Sel_addSubview, dockOverlayView)
+		Objc_sendMsg[uintptr](tile, Sel_setContentView, dockIconView)
+	}
+
+	switch {
+	case icon != nil:
+		if err := setImageViewImage(dockIconView, icon); err != nil {
+			return err
+		}
+	case !dockCustomIcon:
+		app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+		appIcon := Objc_sendMsg[uintptr](app, Sel_applicationIconImage)
+		Objc_sendMsg[uintptr](dockIconView, Sel_setImage, appIcon)
+	}
+
+	if dockProgress < 0 {
+		Objc_sendMsg[uintptr](dockOverlayView, Sel_setImage, uintptr(0))
+	} else if err := setImageViewImage(dockOverlayView, dockProgressImage(dockOverlaySize, dockProgress)); err != nil {
+		return err
+	}
+	Objc_sendMsg[uintptr](tile, Sel_display)
+	return nil
+}
+
+func newDockImageView(frame NSRect) uintptr {
+	var initWithFrame func(uintptr, Selector, NSRect) uintptr
+	purego.RegisterLibFunc(&initWithFrame, libobjc, "objc_msgSend")
+	view := initWithFrame(Objc_sendMsg[uintptr](Class_NSImageView, Sel_alloc), Sel_initWithFrame, frame)
+	Objc_sendMsg[uintptr](view, Sel_setImageScaling, nsImageScaleProportionallyUpOrDown)
+	return view
+}
+
+func setImageViewImage(view uintptr, img image.Image) error {
+	nsImage, err := nsImageFromGoImage(img)
+	if err != nil {
+		return err
+	}
+	Objc_sendMsg[uintptr](view, Sel_setImage, uintptr(nsImage.Ptr))
+	nsImage.Release()
+	return nil
+}
+
+func releaseDockViews() {
+	if dockIconView == 0 {
+		return
+	}
+	Objc_sendMsg[uintptr](dockOverlayView, Sel_release)
+	Objc_sendMsg[uintptr](dockIconView, Sel_release)
+	dockIconView, dockOverlayView = 0, 0
+}
+
+// SetDockMenu sets the items AppKit adds to the application's Dock menu. It is
+// rebuilt each time the Dock menu opens, so later changes to menu show up
+// the next time. Menu roles are ignored. A nil menu removes the items.
+func SetDockMenu(menu *Menu) {
+	checkMainThread("SetDockMenu")
+	dockMenu = menu
+}
+
+var (
+	dockMenu       *Menu
+	dockNativeMenu uintptr
+	dockMenuTags   [2]int
+)
+
+// applicationDockMenu builds the Dock menu afresh. AppKit does not take
+// ownership of the returned menu, so the previous one is kept alive until the
+// next call, when its actions have had their chance to run.
+func applicationDockMenu(id, sel, sender uintptr) (menu uintptr) {
+	runCallback(func() { menu = buildDockMenu() })
+	return menu
+}
+
+func buildDockMenu() uintptr {
+	deleteMenuEntries(dockMenuTags)
+	if dockNativeMenu != 0 {
+		Objc_sendMsg[uintptr](dockNativeMenu, Sel_release)
+		dockNativeMenu = 0
+	}
+	if dockMenu == nil {
+		return 0
+	}
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	skipMenuRoles = true
+	defer func() { skipMenuRoles = false }()
+	dockMenuTags[0] = nextMenuAction
+	dockNativeMenu = newNativeMenu(app, dockMenu)
+	dockMenuTags[1] = nextMenuAction
+	return dockNativeMenu
+}
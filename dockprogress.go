@@ -0,0 +1,62 @@
+package darwin
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// clampDockProgress limits progress to at most 1. A negative progress, which
+// removes the bar, is kept as is.
+func clampDockProgress(progress float64) float64 {
+	if progress < 0 {
+		return progress
+	}
+	return min(progress, 1)
+}
+
+// The colors of the progress bar's track and of its filled part.
+var (
+	dockProgressTrack = color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xC0}
+	dockProgressFill  = color.RGBA{R: 0x0A, G: 0x84, B: 0xFF, A: 0xFF}
+)
+
+// dockProgressImage draws a size×size transparent image with a rounded
+// progress bar near the bottom, in the style of Finder's copy progress. The
+// bar is filled to progress, clamped to [0, 1].
+func dockProgressImage(size int, progress float64) image.Image {
+	progress = min(max(progress, 0), 1)
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	barHeight := size / 10
+	inset := size / 8
+	bar := image.Rect(inset, size-inset-barHeight, size-inset, size-inset)
+
+	drawRoundedBar(img, bar, dockProgressTrack)
+
+	filled := bar
+	filled.Max.X = bar.Min.X + int(float64(bar.Dx())*progress)
+	if filled.Dx() > 0 {
+		drawRoundedBar(img, filled.Inset(max(1, barHeight/8)), dockProgressFill)
+	}
+	return img
+}
+
+// drawRoundedBar fills r with c, rounding its ends into semicircles.
+func drawRoundedBar(img draw.Image, r image.Rectangle, c color.Color) {
+	radius := float64(r.Dy()) / 2
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		dy := float64(y-r.Min.Y) + 0.5 - radius
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var dx float64
+			switch left, right := float64(r.Min.X)+radius, float64(r.Max.X)-radius; {
+			case float64(x)+0.5 < left:
+				dx = left - float64(x) - 0.5
+			case float64(x)+0.5 > right:
+				dx = float64(x) + 0.5 - right
+			}
+			if dx*dx+dy*dy <= radius*radius {
+				img.Set(x, y, c)
+			}
+		}
+	}
+}
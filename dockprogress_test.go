@@ -0,0 +1,87 @@
+package darwin
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestClampDockProgress(t *testing.T) {
+	tests := []struct {
+		in, want float64
+	}{
+		{-1, -1},
+		{0, 0},
+		{0.25, 0.25},
+		{1, 1},
+		{1.5, 1},
+	}
+	for _, tt := range tests {
+		if got := clampDockProgress(tt.in); got != tt.want {
+			t.Errorf("clampDockProgress(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// filledColumns returns the x range of the filled part of the bar on its
+// middle row, or an empty range if nothing is filled.
+func filledColumns(img *image.RGBA) (minX, maxX int) {
+	size := img.Bounds().Dx()
+	y := size - size/8 - size/20
+	minX, maxX = size, 0
+	for x := range size {
+		if img.RGBAAt(x, y) == dockProgressFill {
+			minX, maxX = min(minX, x), max(maxX, x+1)
+		}
+	}
+	return minX, maxX
+}
+
+func TestDockProgressImage(t *testing.T) {
+	const size = 256
+	barMin, barMax := size/8, size-size/8
+
+	empty := dockProgressImage(size, 0).(*image.RGBA)
+	if minX, maxX := filledColumns(empty); minX < maxX {
+		t.Errorf("progress 0 fills columns %d-%d, want none", minX, maxX)
+	}
+	if got := empty.RGBAAt(size/2, size-size/8-size/20); got != dockProgressTrack {
+		t.Errorf("progress 0 track = %v, want %v", got, dockProgressTrack)
+	}
+
+	full := dockProgressImage(size, 1).(*image.RGBA)
+	minX, maxX := filledColumns(full)
+	if minX <= barMin || maxX >= barMax || maxX-minX < barMax-barMin-8 {
+		t.Errorf("progress 1 fills columns %d-%d, want nearly all of %d-%d", minX, maxX, barMin, barMax)
+	}
+
+	half := dockProgressImage(size, 0.5).(*image.RGBA)
+	if _, maxX := filledColumns(half); maxX > size/2 || maxX < size/2-8 {
+		t.Errorf("progress 0.5 fills up to column %d, want about %d", maxX, size/2)
+	}
+
+	if !bytes.Equal(dockProgressImage(size, 2).(*image.RGBA).Pix, full.Pix) {
+		t.Error("progress 2 is not drawn as progress 1")
+	}
+	if !bytes.Equal(dockProgressImage(size, -1).(*image.RGBA).Pix, empty.Pix) {
+		t.Error("progress -1 is not drawn as progress 0")
+	}
+}
+
+func TestDrawRoundedBar(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	c := color.RGBA{R: 0xFF, A: 0xFF}
+	drawRoundedBar(img, img.Bounds(), c)
+
+	for _, p := range []image.Point{{0, 0}, {39, 0}, {0, 9}, {39, 9}} {
+		if got := img.RGBAAt(p.X, p.Y); got == c {
+			t.Errorf("corner %v is filled", p)
+		}
+	}
+	for _, p := range []image.Point{{0, 5}, {20, 0}, {20, 9}, {39, 5}} {
+		if got := img.RGBAAt(p.X, p.Y); got != c {
+			t.Errorf("edge %v = %v, want %v", p, got, c)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package darwin
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// ErrUnsupported is returned by every function in this package when it is
+// built for a platform other than darwin. Use errors.Is to detect it, since
+// the concrete value is an *UnsupportedError naming the call that failed.
+var ErrUnsupported = errors.New("darwin: not supported on this platform")
+
+// UnsupportedError records which API was called on a platform that has no
+// Cocoa runtime.
+type UnsupportedError struct {
+	Op   string
+	GOOS string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "darwin: " + e.Op + " is not supported on " + e.GOOS
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+func unsupported(op string) error {
+	return &UnsupportedError{Op: op, GOOS: runtime.GOOS}
+}
+
+// Sentinel errors returned by the package. They are wrapped with the name of
+// the object or call that failed, so compare them with errors.Is.
+var (
+	ErrAllocFailed            = errors.New("darwin: alloc returned nil")
+	ErrInitFailed             = errors.New("darwin: init returned nil")
+	ErrPixelFormatUnsupported = errors.New("darwin: no pixel format matches the requested attributes")
+	ErrNoApplication          = errors.New("darwin: shared NSApplication instance is nil")
+	ErrNoPasteboard           = errors.New("darwin: general pasteboard is unavailable")
+	ErrPasteboardWrite        = errors.New("darwin: pasteboard rejected the write")
+	ErrImageConversion        = errors.New("darwin: image could not be converted to NSImage")
+	ErrInvalidJoystick        = errors.New("darwin: joystick index out of range")
+	ErrNotImplemented         = errors.New("darwin: not implemented")
+	ErrMainLoopStopped        = errors.New("darwin: main run loop has stopped")
+	ErrInvalidAccelerator     = errors.New("darwin: invalid keyboard accelerator")
+	ErrDisplayCapture         = errors.New("darwin: display could not be captured or released")
+	ErrDisplayGamma           = errors.New("darwin: display gamma could not be set")
+	ErrInvalidGammaTable      = errors.New("darwin: gamma tables must be non-empty and of equal length")
+)
+
+// CGError is a non-zero result code from a CoreGraphics call. Calls that fail
+// with one wrap it alongside the package's sentinel, so errors.As recovers
+// the code.
+type CGError int32
+
+func (e CGError) Error() string {
+	return fmt.Sprintf("CGError %d", int32(e))
+}
+
+// Well-known NSError domains.
+const (
+	NSCocoaErrorDomain    = "NSCocoaErrorDomain"
+	NSPOSIXErrorDomain    = "NSPOSIXErrorDomain"
+	NSOSStatusErrorDomain = "NSOSStatusErrorDomain"
+	NSMachErrorDomain     = "NSMachErrorDomain"
+)
+
+// NSError is a Go copy of a Foundation NSError. It is detached from the native
+// object, so it stays valid after the autorelease pool that owned it drains.
+//
+// Two NSErrors match under errors.Is when their Domain and Code are equal, so
+// a zero-description value works as a target:
+//
+//	errors.Is(err, &darwin.NSError{Domain: darwin.NSCocoaErrorDomain, Code: 4})
+type NSError struct {
+	Domain      string
+	Code        int
+	Description string
+	// UserInfo holds the description of every userInfo value, keyed by the
+	// string form of its key.
+	UserInfo map[string]string
+	// Underlying holds NSUnderlyingErrorKey and
+	// NSMultipleUnderlyingErrorsKey, in that order.
+	Underlying []error
+}
+
+func (e *NSError) Error() string {
+	if e.Description == "" {
+		return fmt.Sprintf("darwin: %s error %d", e.Domain, e.Code)
+	}
+	return fmt.Sprintf("darwin: %s (%s %d)", e.Description, e.Domain, e.Code)
+}
+
+func (e *NSError) Unwrap() []error {
+	return e.Underlying
+}
+
+func (e *NSError) Is(target error) bool {
+	t, ok := target.(*NSError)
+	return ok && t.Domain == e.Domain && t.Code == e.Code
+}
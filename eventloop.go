@@ -0,0 +1,134 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ebitengine/purego"
+)
+
+// appLaunched is set by applicationDidFinishLaunching, whether the launch came
+// from [NSApp run] or from the event pump below.
+var (
+	appLaunched      atomic.Bool
+	finishLaunchOnce sync.Once
+)
+
+// PollEvents processes every event already queued for the application and
+// returns without waiting. Events are forwarded with sendEvent:, so window
+// and view delegates fire as they would under RunApplication. It must be
+// called on the main thread.
+func PollEvents() {
+	checkMainThread("PollEvents")
+	app := ensureLaunched()
+	pumpEvents(app, Objc_sendMsg[uintptr](Class_NSDate, Sel_distantPast))
+}
+
+// WaitEvents sleeps until at least one event arrives or timeout elapses, then
+// processes every queued event as PollEvents does. A negative timeout waits
+// without a deadline. Work dispatched with MainThread and main-thread timers
+// still run while it waits. It must be called on the main thread.
+func WaitEvents(timeout time.Duration) {
+	checkMainThread("WaitEvents")
+	app := ensureLaunched()
+
+	pool := NewAutoreleasePool()
+	var until uintptr
+	if timeout < 0 {
+		until = Objc_sendMsg[uintptr](Class_NSDate, Sel_distantFuture)
+	} else {
+		var dateWithTimeIntervalSinceNow func(uintptr, Selector, float64) uintptr
+		purego.RegisterLibFunc(&dateWithTimeIntervalSinceNow, libobjc, "objc_msgSend")
+		until = dateWithTimeIntervalSinceNow(Class_NSDate, Sel_dateWithTimeIntervalSinceNow, timeout.Seconds())
+	}
+	if event := nextEvent(app, until); event != 0 {
+		Objc_sendMsg[uintptr](app, Sel_sendEvent, event)
+	}
+	pool.Drain()
+
+	pumpEvents(app, Objc_sendMsg[uintptr](Class_NSDate, Sel_distantPast))
+}
+
+// ensureLaunched sends finishLaunching the first time the event pump runs,
+// unless the application already launched through RunApplication. Without it
+// the application never activates and applicationDidFinishLaunching is never
+// delivered.
+func ensureLaunched() uintptr {
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	if !appLaunched.Load() {
+		finishLaunchOnce.Do(func() {
+			Objc_sendMsg[uintptr](app, Sel_finishLaunching)
+			appLaunched.Store(true)
+		})
+	}
+	return app
+}
+
+// pumpEvents dequeues and dispatches events until none arrive before until.
+func pumpEvents(app, until uintptr) {
+	for {
+		pool := NewAutoreleasePool()
+		event := nextEvent(app, until)
+		if event == 0 {
+			pool.Drain()
+			break
+		}
+		Objc_sendMsg[uintptr](app, Sel_sendEvent, event)
+		pool.Drain()
+	}
+	Objc_sendMsg[uintptr](app, Sel_updateWindows)
+}
+
+func nextEvent(app, until uintptr) uintptr {
+	event := Objc_sendMsg[uintptr](app, Sel_nextEventMatchingMaskUntilDateInModeDequeue,
+		uintptr(NSEventMaskAny), until, NSDefaultRunLoopMode, true)
+	if event != 0 && Objc_sendMsg[uintptr](event, Sel_type) == NSEventTypeApplicationDefined {
+		// Re-arm before the event is handled, so a PostEmptyEvent made
+		// meanwhile queues a fresh wake-up.
+		emptyEvents.rearm()
+	}
+	return event
+}
+
+// emptyEvents coalesces PostEmptyEvent calls: only one wake-up event is in
+// the queue at a time.
+var emptyEvents wakeCoalescer
+
+// PostEmptyEvent wakes a main thread blocked in WaitEvents or in an
+// iteration of RunApplication, by posting an application-defined event to the
+// front of the queue. It is safe to call from any goroutine. Calls made
+// before the main thread has dequeued the event are coalesced into one event.
+func PostEmptyEvent() {
+	if !emptyEvents.post(postWakeEvent) {
+		return
+	}
+	// [NSApp run] dequeues events itself, so under RunApplication nextEvent
+	// never sees the wake-up. Re-arm once the main thread has run instead.
+	MainThreadAsync(emptyEvents.rearm)
+}
+
+// postWakeEvent puts an application-defined event at the front of the event
+// queue, so a blocked nextEventMatchingMask: returns. It reports whether the
+// event was posted. It may be called from any thread.
+func postWakeEvent() bool {
+	// The autorelease pool belongs to the current thread.
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	pool := NewAutoreleasePool()
+	defer pool.Drain()
+
+	var otherEventWithType func(uintptr, Selector, uint64, NSPoint, uint64, float64, int64, uintptr, int16, int64, int64) uintptr
+	purego.RegisterLibFunc(&otherEventWithType, libobjc, "objc_msgSend")
+	event := otherEventWithType(Class_NSEvent, Sel_otherEventWithType,
+		NSEventTypeApplicationDefined, NSPoint{}, 0, 0, 0, 0, 0, 0, 0)
+	if event == 0 {
+		return false
+	}
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+	Objc_sendMsg[uintptr](app, Sel_postEventAtStart, event, true)
+	return true
+}
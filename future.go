@@ -0,0 +1,67 @@
+package darwin
+
+// MainThreadValue runs f on the main thread, waits for it, and returns its
+// result. It is the typed form of MainThread for queries such as
+// ContentSize or IsKeyWindow.
+func MainThreadValue[T any](f func() T) T {
+	var v T
+	MainThread(func() {
+		v = f()
+	})
+	return v
+}
+
+// Future is the pending result of a function scheduled with
+// MainThreadFuture.
+type Future[T any] struct {
+	done      chan struct{}
+	value     T
+	recovered *MainThreadPanic
+	err       error
+}
+
+// MainThreadFuture schedules f on the main thread and returns immediately.
+// Several futures can be issued back to back and collected later, so a
+// background goroutine pays for one round trip instead of one per request.
+// If the application terminates before f runs, f is dropped and the future
+// completes with ErrMainLoopStopped.
+func MainThreadFuture[T any](f func() T) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+	dispatch(func() {
+		defer close(fut.done)
+		fut.recovered = runRecovered(func() {
+			fut.value = f()
+		})
+	}, func() {
+		fut.err = ErrMainLoopStopped
+		close(fut.done)
+	})
+	return fut
+}
+
+// Done returns a channel that is closed once the result is available.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err blocks like Wait and returns ErrMainLoopStopped if the function was
+// dropped because the application terminated first, or nil otherwise.
+func (f *Future[T]) Err() error {
+	<-f.done
+	return f.err
+}
+
+// Wait blocks until the function has run and returns its result. If the
+// function panicked, Wait panics with the *MainThreadPanic, and if it was
+// dropped, with ErrMainLoopStopped. Calling Wait from the main thread before
+// the function has run deadlocks.
+func (f *Future[T]) Wait() T {
+	<-f.done
+	if f.err != nil {
+		panic(f.err)
+	}
+	if f.recovered != nil {
+		panic(f.recovered)
+	}
+	return f.value
+}
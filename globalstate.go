@@ -0,0 +1,251 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	_CGAssociateMouseAndMouseCursorPosition,
+	_CGDisplayCapture,
+	_CGDisplayRelease,
+	_CGSetDisplayTransferByTable,
+	_CGDisplayRestoreColorSyncSettings uintptr
+)
+
+// globalState records every change this package makes that outlives the
+// process's windows: the window server keeps it until it is undone, so a
+// crash without cleanup leaves the user's session broken.
+var globalState struct {
+	mu                  sync.Mutex
+	cursorHidden        bool
+	mouseDisassociated  bool
+	presentationChanged bool
+	savedPresentation   PresentationOptions
+	capturedDisplays    map[CGDirectDisplayID]struct{}
+	gammaChanged        bool
+	signals             chan os.Signal
+	signalsDisabled     bool
+}
+
+// restoreTimeout bounds how long RestoreGlobalState waits for the main thread
+// when called from another one.
+const restoreTimeout = time.Second
+
+// RestoreGlobalState undoes every global change still in effect: it shows the
+// cursor, reattaches it to the mouse, restores the presentation options,
+// releases captured displays and resets gamma tables. It runs automatically
+// from applicationWillTerminate, on SIGINT and SIGTERM unless
+// SetRestoreOnSignal(false) was called, and before the default panic handler
+// re-raises a panic from a native callback. It is safe to call from any
+// goroutine and more than once.
+func RestoreGlobalState() {
+	restoreDisplayState()
+	if isMainThread() {
+		restoreAppKitState()
+		return
+	}
+	// The main thread may be the reason we are exiting; do not wait on it
+	// forever.
+	ctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+	defer cancel()
+	_ = MainThreadContext(ctx, restoreAppKitState)
+}
+
+// restoreDisplayState undoes the CoreGraphics changes, which may be made from
+// any thread.
+func restoreDisplayState() {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	if globalState.mouseDisassociated {
+		purego.SyscallN(_CGAssociateMouseAndMouseCursorPosition, 1)
+		globalState.mouseDisassociated = false
+	}
+	for id := range globalState.capturedDisplays {
+		purego.SyscallN(_CGDisplayRelease, uintptr(id))
+		delete(globalState.capturedDisplays, id)
+	}
+	if globalState.gammaChanged {
+		purego.SyscallN(_CGDisplayRestoreColorSyncSettings)
+		globalState.gammaChanged = false
+	}
+}
+
+// restoreAppKitState undoes the AppKit changes. It runs on the main thread.
+func restoreAppKitState() {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	if globalState.cursorHidden {
+		Objc_sendMsg[uintptr](Class_NSCursor, Sel_unhide)
+		globalState.cursorHidden = false
+	}
+	if globalState.presentationChanged {
+		app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+		Objc_sendMsg[uintptr](app, Sel_setPresentationOptions, uintptr(globalState.savedPresentation))
+		globalState.presentationChanged = false
+	}
+}
+
+// watchTerminationSignals installs the SIGINT and SIGTERM handler the first
+// time a global change is made, unless SetRestoreOnSignal(false) was called.
+// The handler restores state, then re-raises the signal with the handler
+// removed so the process still exits as it would have. globalState.mu must
+// be held.
+func watchTerminationSignals() {
+	if globalState.signals != nil || globalState.signalsDisabled {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	globalState.signals = ch
+	go func() {
+		sig, ok := <-ch
+		if !ok {
+			return
+		}
+		RestoreGlobalState()
+		signal.Stop(ch)
+		syscall.Kill(os.Getpid(), sig.(syscall.Signal))
+	}()
+}
+
+// SetRestoreOnSignal sets whether SIGINT and SIGTERM run RestoreGlobalState.
+// It is on by default. A program that handles these signals itself should
+// turn it off and call RestoreGlobalState from its own handler: otherwise it
+// receives the signal a second time once state is restored, and is killed
+// by it. It is safe to call from any goroutine.
+func SetRestoreOnSignal(enabled bool) {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	globalState.signalsDisabled = !enabled
+	if !enabled && globalState.signals != nil {
+		signal.Stop(globalState.signals)
+		close(globalState.signals)
+		globalState.signals = nil
+	}
+}
+
+func SetCursorMode(mode DarwinCursorMode) {
+	checkMainThread("SetCursorMode")
+	hide := mode == DarwinCursorHidden || mode == DarwinCursorDisabled
+
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	watchTerminationSignals()
+	// NSCursor counts hides, so only send one per state change.
+	if hide != globalState.cursorHidden {
+		if hide {
+			Objc_sendMsg[uintptr](Class_NSCursor, Sel_hideCursor)
+		} else {
+			Objc_sendMsg[uintptr](Class_NSCursor, Sel_unhide)
+		}
+		globalState.cursorHidden = hide
+	}
+}
+
+// SetMouseCursorAssociated attaches the cursor to the mouse, or detaches it
+// so the cursor stays put while mouse movement still produces deltas, as a
+// game's mouse look needs. A detached cursor is reattached by
+// RestoreGlobalState. It is safe to call from any goroutine.
+func SetMouseCursorAssociated(associated bool) error {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	watchTerminationSignals()
+	ret, _, _ := purego.SyscallN(_CGAssociateMouseAndMouseCursorPosition, boolToUintptr(associated))
+	if int32(ret) != 0 {
+		return fmt.Errorf("darwin: CGAssociateMouseAndMouseCursorPosition: %w", CGError(ret))
+	}
+	globalState.mouseDisassociated = !associated
+	return nil
+}
+
+// SetPresentationOptions sets how the Dock, menu bar and system shortcuts
+// behave while the application is active. The options in effect before the
+// first call are restored by RestoreGlobalState.
+func SetPresentationOptions(opts PresentationOptions) {
+	checkMainThread("SetPresentationOptions")
+	app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	watchTerminationSignals()
+	if !globalState.presentationChanged {
+		globalState.savedPresentation = PresentationOptions(Objc_sendMsg[uintptr](app, Sel_presentationOptions))
+		globalState.presentationChanged = true
+	}
+	Objc_sendMsg[uintptr](app, Sel_setPresentationOptions, uintptr(opts))
+}
+
+// CaptureDisplay takes exclusive use of a display, blanking it for other
+// applications, until ReleaseDisplay or RestoreGlobalState. A failure wraps
+// ErrDisplayCapture and the CGError.
+func CaptureDisplay(display CGDirectDisplayID) error {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	watchTerminationSignals()
+	if ret, _, _ := purego.SyscallN(_CGDisplayCapture, uintptr(display)); int32(ret) != 0 {
+		return fmt.Errorf("%w: CGDisplayCapture: %w", ErrDisplayCapture, CGError(ret))
+	}
+	if globalState.capturedDisplays == nil {
+		globalState.capturedDisplays = make(map[CGDirectDisplayID]struct{})
+	}
+	globalState.capturedDisplays[display] = struct{}{}
+	return nil
+}
+
+// ReleaseDisplay gives up a display taken with CaptureDisplay.
+func ReleaseDisplay(display CGDirectDisplayID) error {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	if ret, _, _ := purego.SyscallN(_CGDisplayRelease, uintptr(display)); int32(ret) != 0 {
+		return fmt.Errorf("%w: CGDisplayRelease: %w", ErrDisplayCapture, CGError(ret))
+	}
+	delete(globalState.capturedDisplays, display)
+	return nil
+}
+
+// SetDisplayGamma replaces the gamma ramp of a display with the given tables,
+// which must have the same length. Each entry is an output level from 0 to 1.
+// Tables of the wrong shape give ErrInvalidGammaTable; a failure in
+// CoreGraphics wraps ErrDisplayGamma and the CGError.
+func SetDisplayGamma(display CGDirectDisplayID, red, green, blue []float32) error {
+	if len(red) == 0 || len(green) != len(red) || len(blue) != len(red) {
+		return fmt.Errorf("%w: SetDisplayGamma", ErrInvalidGammaTable)
+	}
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	watchTerminationSignals()
+	ret, _, _ := purego.SyscallN(_CGSetDisplayTransferByTable, uintptr(display), uintptr(len(red)),
+		uintptr(unsafe.Pointer(&red[0])), uintptr(unsafe.Pointer(&green[0])), uintptr(unsafe.Pointer(&blue[0])))
+	if int32(ret) != 0 {
+		return fmt.Errorf("%w: CGSetDisplayTransferByTable: %w", ErrDisplayGamma, CGError(ret))
+	}
+	globalState.gammaChanged = true
+	return nil
+}
+
+// RestoreDisplayGamma resets the gamma ramps of every display to the user's
+// ColorSync settings.
+func RestoreDisplayGamma() {
+	globalState.mu.Lock()
+	defer globalState.mu.Unlock()
+	purego.SyscallN(_CGDisplayRestoreColorSyncSettings)
+	globalState.gammaChanged = false
+}
+
+func boolToUintptr(b bool) uintptr {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -1,9 +1,10 @@
+//go:build darwin
+
 package darwin
 
 import (
 	"fmt"
 	"reflect"
-	"sync"
 	"unsafe"
 
 	"github.com/ebitengine/purego"
@@ -79,23 +80,6 @@ func Objc_alloc_init(class uintptr) uintptr {
 	return Objc_sendMsg[uintptr](obj, Sel_init)
 }
 
-func NewCString(s string) *byte {
-	b := make([]byte, len(s)+1)
-	copy(b, s)
-	return &b[0]
-}
-
-func GoString(s uintptr) string {
-	if s == 0 {
-		return ""
-	}
-	var l int
-	for *(*byte)(unsafe.Pointer(s + uintptr(l))) != 0 {
-		l++
-	}
-	return string(unsafe.Slice((*byte)(unsafe.Pointer(s)), l))
-}
-
 // Sel_getUid registers a selector name with the Objective-C runtime and returns its handle.
 func Sel_getUid(name string) Selector {
 	ret, _, _ := purego.SyscallN(uintptr(Sel_registerName), uintptr(unsafe.Pointer(NewCString(name))))
@@ -120,32 +104,3 @@ func objc_allocateClassPair(superclass uintptr, name string, extraBytes uintptr)
 func objc_registerClassPair(class uintptr) {
 	purego.SyscallN(objc_registerClassPair_ptr, class)
 }
-
-// goPointers is a map used to associate a simple integer ID with a Go interface{}.
-// This allows us to safely pass a reference to a Go object into the C/Objective-C world.
-var (
-	goPointers    = make(map[uintptr]any)
-	goPointersMtx sync.RWMutex
-	goPointerID   uintptr
-)
-
-func StoreGoPointer(v any) uintptr {
-	goPointersMtx.Lock()
-	defer goPointersMtx.Unlock()
-	goPointerID++
-	ptr := goPointerID
-	goPointers[ptr] = v
-	return ptr
-}
-
-func GetGoPointer(ptr uintptr) any {
-	goPointersMtx.RLock()
-	defer goPointersMtx.RUnlock()
-	return goPointers[ptr]
-}
-
-func FreeGoPointer(ptr uintptr) {
-	goPointersMtx.Lock()
-	defer goPointersMtx.Unlock()
-	delete(goPointers, ptr)
-}
@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -10,28 +12,7 @@ import (
 )
 
 var (
-	libAppKit, libFoundation, libCoreGraphics, libCoreOpenGL, libIOKit, libobjc, libCoreVideo uintptr
-)
-
-var (
-	Class_NSApplication, Class_NSString, Class_NSWindow, Class_NSPasteboard, Class_NSOpenGLContext, Class_NSObject, Class_NSCursor, Class_NSImage, Class_NSBitmapImageRep, Class_cocoaWindowDelegate, Class_NSMenu, Class_NSMenuItem, Class_appDelegate, Class_NSOpenGLView, Class_NSAutoreleasePool, Class_NSThread, Class_NSOpenGLPixelFormat, Class_NSScreen, Class_NSRunLoop, Class_NSDictionary, Class_NSArray, Class_NSNumber, Class_NSTrackingArea, Class_NSColor, Class_NSImageView uintptr
-)
-
-var (
-	// Application & Lifecycle Selectors
-	Sel_registerName, Sel_alloc, Sel_init, Sel_release, Sel_retain, Sel_autorelease, Sel_drain, Sel_sharedApplication, Sel_setDelegate, Sel_delegate, Sel_setActivationPolicy, Sel_run, Sel_terminate, Sel_stop, Sel_activateIgnoringOtherApps, Sel_applicationDidFinishLaunching, Sel_applicationShouldTerminateAfterLastWindowClosed, Sel_applicationWillTerminate, Sel_new, Sel_isMainThread, Sel_performSelectorOnMainThread, Sel_call, Sel_mainRunLoop, Sel_class,
-	// Menu Selectors
-	Sel_setMainMenu, Sel_addItem, Sel_setSubmenu, Sel_addItemWithTitleActionKeyEquivalent, Sel_hide, Sel_hideOtherApplications, Sel_unhideAllApplications, Sel_miniaturize, Sel_performMiniaturize, Sel_performClose, Sel_selectAll, Sel_copy, Sel_paste, Sel_cut, Sel_undo, Sel_redo, Sel_setServicesMenu,
-	// Window & View Selectors
-	Sel_initWithContentRectStyleMaskBackingDefer, Sel_setTitle, Sel_setContentView, Sel_contentView, Sel_setOpenGLContext, Sel_makeCurrentContext, Sel_update, Sel_prepareOpenGL, Sel_clearCurrentContext, Sel_flushBuffer, Sel_CGLContextObj, Sel_close, Sel_backingScaleFactor, Sel_isKeyWindow, Sel_makeKeyAndOrderFront, Sel_toggleFullScreen, Sel_styleMask, Sel_setAutoresizingMask, Sel_initWithFrame, Sel_frame, Sel_setFrameTopLeftPoint, Sel_nextEventMatchingMaskUntilDateInModeDequeue, Sel_sendEvent, Sel_window, Sel_windowShouldClose, Sel_windowDidResize, Sel_object, Sel_setWantsBestResolutionOpenGLSurface, Sel_makeFirstResponder, Sel_acceptsFirstResponder, Sel_updateTrackingAreas, Sel_addTrackingArea, Sel_initWithRectOptionsOwnerUserInfo, Sel_initWithAttributes, Sel_screen, Sel_mainScreen, Sel_set, Sel_unhide, Sel_viewDidMoveToWindow, Sel_setBackgroundColor, Sel_colorWithSRGB, Sel_setTitlebarAppearsTransparent, Sel_setTitleVisibility, Sel_setWindowLevel, Sel_setCollectionBehavior,
-	// Event Selectors
-	Sel_keyCode, Sel_modifierFlags, Sel_characters, Sel_locationInWindow, Sel_scrollingDeltaX, Sel_scrollingDeltaY, Sel_buttonNumber, Sel_clickCount, Sel_phase, Sel_magnification, Sel_rotation, Sel_mouseMoved, Sel_mouseDragged, Sel_mouseDown, Sel_mouseUp, Sel_scrollWheel, Sel_keyDown, Sel_keyUp, Sel_flagsChanged, Sel_magnifyWithEvent, Sel_rotateWithEvent, Sel_swipeWithEvent, Sel_deltaX, Sel_deltaY,
-	// Drag and Drop Selectors
-	Sel_registerForDraggedTypes, Sel_draggingEntered, Sel_performDragOperation, Sel_concludeDragOperation, Sel_draggingPasteboard,
-	// Pasteboard & String Selectors
-	Sel_generalPasteboard, Sel_stringForType, Sel_setStringForType, Sel_UTF8String, Sel_initWithUTF8String, Sel_pboardTypeFileURL,
-	// Collection & Number Selectors
-	Sel_count, Sel_objectAtIndex, Sel_numberWithInt, Sel_dictionaryWithObjectsForKeysCount, Sel_arrayWithObjects, Sel_unsignedLongLongValue, Sel_deviceDescription Selector
+	libAppKit, libFoundation, libCoreGraphics, libCoreOpenGL, libIOKit, libobjc, libCoreVideo, libSystem uintptr
 )
 
 var (
@@ -39,23 +20,28 @@ var (
 )
 
 var (
-	NSPasteboardTypeFileURL uintptr
-)
-
-var (
-	objc_allocateClassPair_ptr, objc_registerClassPair_ptr, sel_getName_ptr, class_addMethod_ptr, object_setInstanceVariable_ptr, object_getInstanceVariable_ptr, class_addIvar_ptr uintptr
+	objc_allocateClassPair_ptr, objc_registerClassPair_ptr, sel_getName_ptr, class_addMethod_ptr, object_setInstanceVariable_ptr, object_getInstanceVariable_ptr, class_addIvar_ptr                                                                                                                                                   uintptr
+	objc_initWeak_ptr, objc_loadWeakRetained_ptr, objc_destroyWeak_ptr                                                                                                                                                                                                                                                                uintptr
 	_CGWarpMouseCursorPosition, _CGLFlushDrawable, _CFStringCreateWithCString, _CFNumberCreate, _IOHIDManagerCreate, _CFDictionaryCreateMutable, _IOHIDManagerSetDeviceMatchingMultiple, _IOHIDManagerRegisterDeviceMatchingCallback, _IOHIDManagerRegisterDeviceRemovalCallback, _IOHIDManagerScheduleWithRunLoop, _IOHIDManagerOpen uintptr
 	_IOHIDDeviceGetProperty, _IOHIDDeviceCopyMatchingElements, _CFRelease, _CFArrayGetCount, _CFArrayGetValueAtIndex                                                                                                                                                                                                                  uintptr
 	_IOHIDElementGetUsagePage, _IOHIDElementGetUsage, _IOHIDElementGetType, _IOHIDElementGetLogicalMin, _IOHIDElementGetLogicalMax, _IOHIDDeviceGetValue, _IOHIDValueGetIntegerValue                                                                                                                                                  uintptr
 )
 
+// Lock the main goroutine to the process's first thread while it is still
+// there. AppKit only works on that thread, and Initialize, Main and
+// RunApplication rely on the main goroutine never migrating off it.
+func init() {
+	runtime.LockOSThread()
+}
+
 var initOnce sync.Once
 
-func Initialize() {
+// Initialize loads the system frameworks and registers the package's
+// Objective-C classes. It must be called from the main goroutine before any
+// other function in this package. On platforms other than darwin it returns
+// ErrUnsupported.
+func Initialize() error {
 	initOnce.Do(func() {
-		if runtime.GOOS != "darwin" {
-			return
-		}
 		runtime.LockOSThread()
 
 		mustLoadLibraries()
@@ -66,8 +52,10 @@ func Initialize() {
 
 		setupAppDelegateClass()
 		setupWindowDelegateClass()
-		setupGoCallbackClass()
+		setupMenuTargetClass()
+		setupMainQueueSource()
 	})
+	return nil
 }
 
 func mustLoadLibraries() {
@@ -100,6 +88,10 @@ func mustLoadLibraries() {
 	if openErr != nil {
 		panic("failed to load CoreVideo: " + openErr.Error())
 	}
+	libSystem, openErr = purego.Dlopen("/usr/lib/libSystem.B.dylib", purego.RTLD_LAZY)
+	if openErr != nil {
+		panic("failed to load libSystem: " + openErr.Error())
+	}
 }
 
 func mustLoadFunctions() {
@@ -122,8 +114,16 @@ func mustLoadFunctions() {
 	object_setInstanceVariable_ptr = load(libobjc, "object_setInstanceVariable")
 	object_getInstanceVariable_ptr = load(libobjc, "object_getInstanceVariable")
 	class_addIvar_ptr = load(libobjc, "class_addIvar")
+	objc_initWeak_ptr = load(libobjc, "objc_initWeak")
+	objc_loadWeakRetained_ptr = load(libobjc, "objc_loadWeakRetained")
+	objc_destroyWeak_ptr = load(libobjc, "objc_destroyWeak")
 
 	_CGWarpMouseCursorPosition = load(libCoreGraphics, "CGWarpMouseCursorPosition")
+	_CGAssociateMouseAndMouseCursorPosition = load(libCoreGraphics, "CGAssociateMouseAndMouseCursorPosition")
+	_CGDisplayCapture = load(libCoreGraphics, "CGDisplayCapture")
+	_CGDisplayRelease = load(libCoreGraphics, "CGDisplayRelease")
+	_CGSetDisplayTransferByTable = load(libCoreGraphics, "CGSetDisplayTransferByTable")
+	_CGDisplayRestoreColorSyncSettings = load(libCoreGraphics, "CGDisplayRestoreColorSyncSettings")
 	_CGLFlushDrawable = load(libCoreOpenGL, "CGLFlushDrawable")
 	_CFStringCreateWithCString = load(libFoundation, "CFStringCreateWithCString")
 	_CFNumberCreate = load(libFoundation, "CFNumberCreate")
@@ -153,6 +153,50 @@ func mustLoadFunctions() {
 	_CVDisplayLinkStart = load(libCoreVideo, "CVDisplayLinkStart")
 	_CVDisplayLinkStop = load(libCoreVideo, "CVDisplayLinkStop")
 	_CVDisplayLinkRelease = load(libCoreVideo, "CVDisplayLinkRelease")
+
+	_CFRunLoopGetMain = load(libFoundation, "cfRunLoopGetMain")
+	_CFRunLoopRun = load(libFoundation, "cfRunLoopRun")
+	_CFRunLoopStop = load(libFoundation, "cfRunLoopStop")
+	_CFRunLoopWakeUp = load(libFoundation, "cfRunLoopWakeUp")
+	_CFRunLoopSourceCreate = load(libFoundation, "CFRunLoopSourceCreate")
+	_CFRunLoopAddSource = load(libFoundation, "cfRunLoopAddSource")
+	_CFRunLoopSourceSignal = load(libFoundation, "cfRunLoopSourceSignal")
+	_CFAbsoluteTimeGetCurrent = load(libFoundation, "cfAbsoluteTimeGetCurrent")
+	_CFRunLoopTimerCreate = load(libFoundation, "CFRunLoopTimerCreate")
+	_CFRunLoopAddTimer = load(libFoundation, "cfRunLoopAddTimer")
+	_CFRunLoopTimerInvalidate = load(libFoundation, "cfRunLoopTimerInvalidate")
+	_CFRunLoopTimerSetTolerance = load(libFoundation, "cfRunLoopTimerSetTolerance")
+	_CFRunLoopObserverCreate = load(libFoundation, "CFRunLoopObserverCreate")
+	_CFRunLoopAddObserver = load(libFoundation, "cfRunLoopAddObserver")
+	_CFRunLoopObserverInvalidate = load(libFoundation, "cfRunLoopObserverInvalidate")
+
+	_dispatch_queue_create = load(libSystem, "dispatch_queue_create")
+	_dispatch_queue_attr_make_with_qos_class = load(libSystem, "dispatch_queue_attr_make_with_qos_class")
+	_dispatch_get_global_queue = load(libSystem, "dispatch_get_global_queue")
+	_dispatch_async_f = load(libSystem, "dispatch_async_f")
+	_dispatch_after_f = load(libSystem, "dispatch_after_f")
+	_dispatch_time = load(libSystem, "dispatch_time")
+	_dispatch_release = load(libSystem, "dispatch_release")
+	_dispatch_group_create = load(libSystem, "dispatch_group_create")
+	_dispatch_group_async_f = load(libSystem, "dispatch_group_async_f")
+	_dispatch_group_notify_f = load(libSystem, "dispatch_group_notify_f")
+	_dispatch_group_enter = load(libSystem, "dispatch_group_enter")
+	_dispatch_group_leave = load(libSystem, "dispatch_group_leave")
+	_dispatch_group_wait = load(libSystem, "dispatch_group_wait")
+	_dispatch_semaphore_create = load(libSystem, "dispatch_semaphore_create")
+	_dispatch_semaphore_signal = load(libSystem, "dispatch_semaphore_signal")
+	_dispatch_semaphore_wait = load(libSystem, "dispatch_semaphore_wait")
+	_mach_absolute_time = load(libSystem, "mach_absolute_time")
+	_mach_timebase_info = load(libSystem, "mach_timebase_info")
+	_mach_thread_self = load(libSystem, "mach_thread_self")
+	_mach_port_deallocate = load(libSystem, "mach_port_deallocate")
+	_thread_policy_set = load(libSystem, "thread_policy_set")
+	_pthread_set_qos_class_self_np = load(libSystem, "pthread_set_qos_class_self_np")
+	machTaskSelf = (*uint32)(unsafe.Pointer(load(libSystem, "mach_task_self_")))
+	// The main queue and the concurrent attribute are data symbols; the
+	// symbol address is the object itself.
+	dispatchMainQueue = DispatchQueue(load(libSystem, "_dispatch_main_q"))
+	dispatchQueueAttrConcurrent = load(libSystem, "_dispatch_queue_attr_concurrent")
 }
 
 func mustLoadClasses() {
@@ -190,6 +234,10 @@ func mustLoadClasses() {
 	Class_NSTrackingArea = getClass("NSTrackingArea")
 	Class_NSColor = getClass("NSColor")
 	Class_NSImageView = getClass("NSImageView")
+	Class_NSError = getClass("NSError")
+	Class_NSProcessInfo = getClass("NSProcessInfo")
+	Class_NSDate = getClass("NSDate")
+	Class_NSEvent = getClass("NSEvent")
 }
 
 func mustLoadConstants() {
@@ -202,6 +250,7 @@ func mustLoadConstants() {
 	}
 	NSDefaultRunLoopMode = loadConstant(libFoundation, "NSDefaultRunLoopMode")
 	NSPasteboardTypeFileURL = loadConstant(libAppKit, "NSPasteboardTypeFileURL")
+	kCFRunLoopCommonModes = loadConstant(libFoundation, "kCFRunLoopCommonModes")
 }
 
 func mustRegisterSelectors() {
@@ -218,6 +267,11 @@ func mustRegisterSelectors() {
 	Sel_setMainMenu = Sel_getUid("setMainMenu:")
 	Sel_addItem = Sel_getUid("addItem:")
 	Sel_setSubmenu = Sel_getUid("setSubmenu:")
+	Sel_setWindowsMenu = Sel_getUid("setWindowsMenu:")
+	Sel_setHelpMenu = Sel_getUid("setHelpMenu:")
+	Sel_orderFrontStandardAboutPanel = Sel_getUid("orderFrontStandardAboutPanel:")
+	Sel_performZoom = Sel_getUid("performZoom:")
+	Sel_arrangeInFront = Sel_getUid("arrangeInFront:")
 	Sel_addItemWithTitleActionKeyEquivalent = Sel_getUid("addItemWithTitle:action:keyEquivalent:")
 	Sel_run = Sel_getUid("run")
 	Sel_terminate = Sel_getUid("terminate:")
@@ -285,12 +339,15 @@ func mustRegisterSelectors() {
 	Sel_buttonNumber = Sel_getUid("buttonNumber")
 	Sel_clickCount = Sel_getUid("clickCount")
 	Sel_phase = Sel_getUid("phase")
+	Sel_type = Sel_getUid("type")
 	Sel_magnification = Sel_getUid("magnification")
 	Sel_rotation = Sel_getUid("rotation")
 	Sel_class = Sel_getUid("class")
 	Sel_mouseMoved = Sel_getUid("mouseMoved:")
 	Sel_mouseDragged = Sel_getUid("mouseDragged:")
 	Sel_mouseDown = Sel_getUid("mouseDown:")
+	Sel_rightMouseDown = Sel_getUid("rightMouseDown:")
+	Sel_rightMouseUp = Sel_getUid("rightMouseUp:")
 	Sel_mouseUp = Sel_getUid("mouseUp:")
 	Sel_scrollWheel = Sel_getUid("scrollWheel:")
 	Sel_keyDown = Sel_getUid("keyDown:")
@@ -324,13 +381,63 @@ func mustRegisterSelectors() {
 	Sel_initWithAttributes = Sel_getUid("initWithAttributes:")
 	Sel_set = Sel_getUid("set")
 	Sel_unhide = Sel_getUid("unhide")
+	// NSCursor's hide takes no argument, unlike the hide: menu action.
+	Sel_hideCursor = Sel_getUid("hide")
 	Sel_viewDidMoveToWindow = Sel_getUid("viewDidMoveToWindow")
 	Sel_setBackgroundColor = Sel_getUid("setBackgroundColor:")
 	Sel_colorWithSRGB = Sel_getUid("colorWithSRGBRed:green:blue:alpha:")
 	Sel_setTitlebarAppearsTransparent = Sel_getUid("setTitlebarAppearsTransparent:")
 	Sel_setTitleVisibility = Sel_getUid("setTitleVisibility:")
 	Sel_setWindowLevel = Sel_getUid("setLevel:")
-  Sel_setCollectionBehavior = Sel_getUid("setCollectionBehavior:")
+	Sel_setCollectionBehavior = Sel_getUid("setCollectionBehavior:")
+	Sel_domain = Sel_getUid("domain")
+	Sel_code = Sel_getUid("code")
+	Sel_localizedDescription = Sel_getUid("localizedDescription")
+	Sel_userInfo = Sel_getUid("userInfo")
+	Sel_allKeys = Sel_getUid("allKeys")
+	Sel_objectForKey = Sel_getUid("objectForKey:")
+	Sel_description = Sel_getUid("description")
+	Sel_isKindOfClass = Sel_getUid("isKindOfClass:")
+	Sel_processInfo = Sel_getUid("processInfo")
+	Sel_operatingSystemVersion = Sel_getUid("operatingSystemVersion")
+	Sel_respondsToSelector = Sel_getUid("respondsToSelector:")
+	Sel_activate = Sel_getUid("activate")
+	Sel_finishLaunching = Sel_getUid("finishLaunching")
+	Sel_updateWindows = Sel_getUid("updateWindows")
+	Sel_distantPast = Sel_getUid("distantPast")
+	Sel_distantFuture = Sel_getUid("distantFuture")
+	Sel_dateWithTimeIntervalSinceNow = Sel_getUid("dateWithTimeIntervalSinceNow:")
+	Sel_otherEventWithType = Sel_getUid("otherEventWithType:location:modifierFlags:timestamp:windowNumber:context:subtype:data1:data2:")
+	Sel_postEventAtStart = Sel_getUid("postEvent:atStart:")
+	Sel_isRunning = Sel_getUid("isRunning")
+	Sel_presentationOptions = Sel_getUid("presentationOptions")
+	Sel_setPresentationOptions = Sel_getUid("setPresentationOptions:")
+	Sel_menuItemSelected = Sel_getUid("menuItemSelected:")
+	Sel_setTarget = Sel_getUid("setTarget:")
+	Sel_setAction = Sel_getUid("setAction:")
+	Sel_setTag = Sel_getUid("setTag:")
+	Sel_tag = Sel_getUid("tag")
+	Sel_validateMenuItem = Sel_getUid("validateMenuItem:")
+	Sel_sendActionToFrom = Sel_getUid("sendAction:to:from:")
+	Sel_targetForAction = Sel_getUid("targetForAction:")
+	Sel_popUpContextMenuWithEventForView = Sel_getUid("popUpContextMenu:withEvent:forView:")
+	Sel_mouseEventWithType = Sel_getUid("mouseEventWithType:location:modifierFlags:timestamp:windowNumber:context:eventNumber:clickCount:pressure:")
+	Sel_convertPointToView = Sel_getUid("convertPoint:toView:")
+	Sel_windowNumber = Sel_getUid("windowNumber")
+	Sel_dockTile = Sel_getUid("dockTile")
+	Sel_setBadgeLabel = Sel_getUid("setBadgeLabel:")
+	Sel_display = Sel_getUid("display")
+	Sel_size = Sel_getUid("size")
+	Sel_addSubview = Sel_getUid("addSubview:")
+	Sel_applicationIconImage = Sel_getUid("applicationIconImage")
+	Sel_setImageScaling = Sel_getUid("setImageScaling:")
+	Sel_applicationDockMenu = Sel_getUid("applicationDockMenu:")
+	Sel_setEnabled = Sel_getUid("setEnabled:")
+	Sel_setState = Sel_getUid("setState:")
+	Sel_setHidden = Sel_getUid("setHidden:")
+	Sel_setKeyEquivalent = Sel_getUid("setKeyEquivalent:")
+	Sel_setKeyEquivalentModifierMask = Sel_getUid("setKeyEquivalentModifierMask:")
+	Sel_setImage = Sel_getUid("setImage:")
 }
 
 func object_setInstanceVariable(obj uintptr, name string, value unsafe.Pointer) {
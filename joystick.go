@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -43,6 +45,7 @@ const (
 )
 
 func SetupJoysticks() {
+	checkMainThread("SetupJoysticks")
 	pool := NewAutoreleasePool()
 	defer pool.Drain()
 
@@ -94,15 +97,19 @@ func createDeviceMatchingArray() uintptr {
 }
 
 func deviceMatchingCallback(ctx, result, sender, device uintptr) {
-	joystickMtx.Lock()
-	defer joystickMtx.Unlock()
-	addJoystick(IOHIDDeviceRef(device))
+	runCallback(func() {
+		joystickMtx.Lock()
+		defer joystickMtx.Unlock()
+		addJoystick(IOHIDDeviceRef(device))
+	})
 }
 
 func deviceRemovalCallback(ctx, result, sender, device uintptr) {
-	joystickMtx.Lock()
-	defer joystickMtx.Unlock()
-	removeJoystick(IOHIDDeviceRef(device))
+	runCallback(func() {
+		joystickMtx.Lock()
+		defer joystickMtx.Unlock()
+		removeJoystick(IOHIDDeviceRef(device))
+	})
 }
 
 func addJoystick(devRef IOHIDDeviceRef) {
@@ -182,7 +189,7 @@ func GetJoystickAxes(joy int) ([]float32, error) {
 	joystickMtx.Lock()
 	defer joystickMtx.Unlock()
 	if joy < 0 || joy >= len(joysticks) {
-		return nil, fmt.Errorf("joystick index %d is out of bounds", joy)
+		return nil, fmt.Errorf("%w: %d", ErrInvalidJoystick, joy)
 	}
 
 	j := joysticks[joy]
@@ -206,7 +213,7 @@ func GetJoystickButtons(joy int) ([]byte, error) {
 	joystickMtx.Lock()
 	defer joystickMtx.Unlock()
 	if joy < 0 || joy >= len(joysticks) {
-		return nil, fmt.Errorf("joystick index %d is out of bounds", joy)
+		return nil, fmt.Errorf("%w: %d", ErrInvalidJoystick, joy)
 	}
 
 	j := joysticks[joy]
@@ -229,7 +236,7 @@ func GetJoystickHats(joy int) ([]byte, error) {
 	joystickMtx.Lock()
 	defer joystickMtx.Unlock()
 	if joy < 0 || joy >= len(joysticks) {
-		return nil, fmt.Errorf("joystick index %d is out of bounds", joy)
+		return nil, fmt.Errorf("%w: %d", ErrInvalidJoystick, joy)
 	}
 
 	j := joysticks[joy]
@@ -0,0 +1,120 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	_mach_absolute_time,
+	_mach_timebase_info,
+	_mach_thread_self,
+	_mach_port_deallocate,
+	_thread_policy_set,
+	_pthread_set_qos_class_self_np uintptr
+
+	// machTaskSelf points at mach_task_self_, which mach_task_self() reads.
+	machTaskSelf *uint32
+)
+
+var (
+	machTimebaseOnce sync.Once
+	machTimebase     MachTimebase
+)
+
+// MachAbsoluteTime returns the current Mach absolute time, the clock behind
+// CVDisplayLink host times. It does not advance while the system sleeps.
+func MachAbsoluteTime() uint64 {
+	t, _, _ := purego.SyscallN(_mach_absolute_time)
+	return uint64(t)
+}
+
+// MachTimebaseInfo returns the conversion between Mach absolute time and
+// nanoseconds. It is read once and cached.
+func MachTimebaseInfo() MachTimebase {
+	machTimebaseOnce.Do(func() {
+		purego.SyscallN(_mach_timebase_info, uintptr(unsafe.Pointer(&machTimebase)))
+	})
+	return machTimebase
+}
+
+// MachTimeToDuration converts a span of Mach absolute time to a Duration.
+func MachTimeToDuration(ticks uint64) time.Duration {
+	return MachTimebaseInfo().Duration(ticks)
+}
+
+// DurationToMachTime converts d to Mach absolute time units.
+func DurationToMachTime(d time.Duration) uint64 {
+	return MachTimebaseInfo().Ticks(d)
+}
+
+// MachTimeSince returns the time elapsed since the Mach absolute time t, or 0
+// if t is in the future.
+func MachTimeSince(t uint64) time.Duration {
+	now := MachAbsoluteTime()
+	if now < t {
+		return 0
+	}
+	return MachTimeToDuration(now - t)
+}
+
+// SetThreadQoS sets the QoS class of the calling OS thread. relativePriority
+// ranges from -15 to 0 and orders threads within a class. Call it after
+// runtime.LockOSThread; otherwise the goroutine can move to another thread
+// and leave the class behind on this one.
+func SetThreadQoS(qos QoSClass, relativePriority int) error {
+	ret, _, _ := purego.SyscallN(_pthread_set_qos_class_self_np, uintptr(qos), uintptr(relativePriority))
+	if ret != 0 {
+		return fmt.Errorf("pthread_set_qos_class_self_np: %w", syscall.Errno(ret))
+	}
+	return nil
+}
+
+// threadTimeConstraintPolicy is thread_time_constraint_policy_data_t. Every
+// field is in Mach absolute time units.
+type threadTimeConstraintPolicy struct {
+	period      uint32
+	computation uint32
+	constraint  uint32
+	preemptible uint32
+}
+
+const (
+	threadTimeConstraintPolicyFlavor = 2
+	threadTimeConstraintPolicyCount  = 4
+)
+
+// SetThreadTimeConstraint moves the calling OS thread to the real-time
+// scheduling band used for audio and frame rendering. The thread expects to
+// run for computation out of every period, finishing within constraint of the
+// period's start; constraint must be at least computation. With preemptible
+// false the kernel tries not to interrupt the computation. Call it after
+// runtime.LockOSThread. Threads that overrun their computation are demoted by
+// the kernel.
+func SetThreadTimeConstraint(period, computation, constraint time.Duration, preemptible bool) error {
+	tb := MachTimebaseInfo()
+	policy := threadTimeConstraintPolicy{
+		period:      uint32(min(tb.Ticks(period), 1<<32-1)),
+		computation: uint32(min(tb.Ticks(computation), 1<<32-1)),
+		constraint:  uint32(min(tb.Ticks(constraint), 1<<32-1)),
+	}
+	if preemptible {
+		policy.preemptible = 1
+	}
+
+	thread, _, _ := purego.SyscallN(_mach_thread_self)
+	defer purego.SyscallN(_mach_port_deallocate, uintptr(*machTaskSelf), thread)
+	kr, _, _ := purego.SyscallN(_thread_policy_set, thread, threadTimeConstraintPolicyFlavor,
+		uintptr(unsafe.Pointer(&policy)), threadTimeConstraintPolicyCount)
+	if kr != 0 {
+		return fmt.Errorf("thread_policy_set: kern_return_t %d", int32(kr))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package darwin
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// MachTimebase is the ratio between Mach absolute time units and nanoseconds,
+// as reported by mach_timebase_info: nanoseconds = ticks * Numer / Denom. It
+// is 1/1 on Intel Macs and 125/3 on Apple silicon.
+type MachTimebase struct {
+	Numer, Denom uint32
+}
+
+// Duration converts a span of Mach absolute time, such as the difference of
+// two mach_absolute_time readings or two CVTimeStamp host times, to a
+// Duration. Results beyond the range of Duration are clamped.
+func (tb MachTimebase) Duration(ticks uint64) time.Duration {
+	numer, denom := tb.ratio()
+	hi, lo := bits.Mul64(ticks, numer)
+	if hi >= denom {
+		return math.MaxInt64
+	}
+	ns, _ := bits.Div64(hi, lo, denom)
+	if ns > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(ns)
+}
+
+// Ticks converts d to Mach absolute time units, rounding down. Negative
+// durations convert to 0.
+func (tb MachTimebase) Ticks(d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+	numer, denom := tb.ratio()
+	hi, lo := bits.Mul64(uint64(d), denom)
+	if hi >= numer {
+		return math.MaxUint64
+	}
+	ticks, _ := bits.Div64(hi, lo, numer)
+	return ticks
+}
+
+// ratio returns the timebase as uint64s, treating an unset timebase as 1/1.
+func (tb MachTimebase) ratio() (numer, denom uint64) {
+	if tb.Numer == 0 || tb.Denom == 0 {
+		return 1, 1
+	}
+	return uint64(tb.Numer), uint64(tb.Denom)
+}
@@ -0,0 +1,57 @@
+package darwin
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var appleSilicon = MachTimebase{Numer: 125, Denom: 3}
+
+func TestMachTimebaseDuration(t *testing.T) {
+	tests := []struct {
+		tb    MachTimebase
+		ticks uint64
+		want  time.Duration
+	}{
+		{MachTimebase{1, 1}, 16_666_667, 16_666_667},
+		{appleSilicon, 24_000_000, time.Second},
+		{appleSilicon, 400_000, 16_666_666},
+		{appleSilicon, 1, 41},
+		{MachTimebase{}, 1234, 1234},
+		{appleSilicon, math.MaxUint64, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got := tt.tb.Duration(tt.ticks); got != tt.want {
+			t.Errorf("%v.Duration(%d) = %d, want %d", tt.tb, tt.ticks, got, tt.want)
+		}
+	}
+}
+
+func TestMachTimebaseTicks(t *testing.T) {
+	tests := []struct {
+		tb   MachTimebase
+		d    time.Duration
+		want uint64
+	}{
+		{MachTimebase{1, 1}, time.Millisecond, 1_000_000},
+		{appleSilicon, time.Second, 24_000_000},
+		{appleSilicon, 40, 0},
+		{appleSilicon, -time.Second, 0},
+		{MachTimebase{}, 5, 5},
+	}
+	for _, tt := range tests {
+		if got := tt.tb.Ticks(tt.d); got != tt.want {
+			t.Errorf("%v.Ticks(%d) = %d, want %d", tt.tb, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestMachTimebaseRoundTrip(t *testing.T) {
+	for _, d := range []time.Duration{0, 125, time.Microsecond, 16_666_667, time.Hour} {
+		got := appleSilicon.Duration(appleSilicon.Ticks(d))
+		if diff := d - got; diff < 0 || diff >= 125/3+1 {
+			t.Errorf("round trip of %v gave %v", d, got)
+		}
+	}
+}
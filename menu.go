@@ -0,0 +1,243 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"github.com/ebitengine/purego"
+)
+
+// Menu items with a Go action target a single GoMenuTarget instance and send
+// it menuItemSelected:. The item's tag is the key of its entry in menuEntries,
+// so one native method serves every item.
+var menuTarget uintptr
+
+// menuEntry is the Go side of a menu item bound to the Go target. An entry
+// with a selector forwards it along the responder chain instead of running a
+// Go action; context menus bind every item that way so the pick is seen.
+type menuEntry struct {
+	item     MenuItem
+	action   func()
+	selector Selector
+	validate func() bool
+	enabled  bool
+}
+
+// menuEntries holds the entries of the current menu bar, of an open context
+// menu and of the last Dock menu. Each of them owns a range of tags, so one
+// can be dropped without touching the others. It is only touched on the main
+// thread.
+var (
+	menuEntries    = make(map[int]*menuEntry)
+	nextMenuAction = 1
+	mainMenuTags   [2]int
+)
+
+// While a context menu is being built, routeMenuSelectors binds items with a
+// selector Action to the Go target too; while it is open, menuPicked receives
+// the entry of the chosen item.
+var (
+	routeMenuSelectors bool
+	menuPicked         func(*menuEntry)
+)
+
+// skipMenuRoles is set while a context or Dock menu is built, so its
+// submenus do not take the Windows, Help or Services role from the menu bar.
+var skipMenuRoles bool
+
+var (
+	menuCommandMu      sync.Mutex
+	menuCommandHandler func(command int)
+)
+
+// SetMenuCommandHandler sets the function that receives the Command of a
+// selected menu item that has no OnSelect. It runs on the main thread.
+func SetMenuCommandHandler(f func(command int)) {
+	menuCommandMu.Lock()
+	menuCommandHandler = f
+	menuCommandMu.Unlock()
+}
+
+// menuItemAction returns the Go action for item, or nil if the item uses a
+// plain selector.
+func menuItemAction(item MenuItem) func() {
+	if item.OnSelect != nil {
+		return item.OnSelect
+	}
+	if item.Command != 0 {
+		command := item.Command
+		return func() {
+			menuCommandMu.Lock()
+			f := menuCommandHandler
+			menuCommandMu.Unlock()
+			if f != nil {
+				f(command)
+			}
+		}
+	}
+	return nil
+}
+
+// bindMenuItem points menuItem at the Go target if item has a Go action, or
+// a selector that should be routed through it, and records its entry under a
+// fresh tag.
+func bindMenuItem(menuItem uintptr, item MenuItem) {
+	e := &menuEntry{item: item, validate: item.Validate, enabled: true}
+	switch {
+	case item.Action != 0 && routeMenuSelectors:
+		e.selector = item.Action
+	case item.Action == 0:
+		e.action = menuItemAction(item)
+	}
+	if e.action == nil && e.selector == 0 {
+		return
+	}
+	tag := nextMenuAction
+	nextMenuAction++
+	menuEntries[tag] = e
+	Objc_sendMsg[uintptr](menuItem, Sel_setTarget, menuTarget)
+	Objc_sendMsg[uintptr](menuItem, Sel_setAction, Sel_menuItemSelected)
+	Objc_sendMsg[uintptr](menuItem, Sel_setTag, tag)
+}
+
+// resetMenuActions drops the entries of the previous menu bar, so closures
+// that are no longer reachable from a menu item can be collected. Entries of
+// an open context menu or of the Dock menu are left alone. Tags keep counting
+// up, so an item left over from an old menu can never reach the entry of a
+// new one.
+func resetMenuActions() {
+	deleteMenuEntries(mainMenuTags)
+	mainMenuTags = [2]int{}
+}
+
+// deleteMenuEntries drops the entries with tags in [tags[0], tags[1]).
+func deleteMenuEntries(tags [2]int) {
+	for tag := tags[0]; tag < tags[1]; tag++ {
+		delete(menuEntries, tag)
+	}
+}
+
+// menuEntryFor returns the entry of a menu item bound to the Go target, or nil.
+func menuEntryFor(menuItem uintptr) *menuEntry {
+	return menuEntries[int(Objc_sendMsg[uintptr](menuItem, Sel_tag))]
+}
+
+func menuItemSelected(id, sel, sender uintptr) {
+	runCallback(func() { selectMenuItem(sender) })
+}
+
+func selectMenuItem(sender uintptr) {
+	e := menuEntryFor(sender)
+	if e == nil {
+		return
+	}
+	if menuPicked != nil {
+		menuPicked(e)
+	}
+	if e.selector != 0 {
+		app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+		Objc_sendMsg[bool](app, Sel_sendActionToFrom, e.selector, uintptr(0), sender)
+		return
+	}
+	e.action()
+}
+
+// validateMenuItem is called by AppKit for each Go-bound item just before its
+// menu opens or its key equivalent is matched.
+func validateMenuItem(id, sel, menuItem uintptr) (enabled bool) {
+	runCallback(func() { enabled = menuItemEnabled(menuItem) })
+	return enabled
+}
+
+func menuItemEnabled(menuItem uintptr) bool {
+	e := menuEntryFor(menuItem)
+	if e == nil {
+		return false
+	}
+	if e.selector != 0 {
+		// Enabled when something in the responder chain would handle it.
+		app := Objc_sendMsg[uintptr](Class_NSApplication, Sel_sharedApplication)
+		return Objc_sendMsg[uintptr](app, Sel_targetForAction, e.selector) != 0
+	}
+	if e.validate == nil {
+		return e.enabled
+	}
+	return e.validate()
+}
+
+// SetEnabled enables or disables the item. For items with a Go action it
+// takes effect the next time AppKit validates the item, unless the item has
+// a Validate function. Items with a selector Action are validated by their
+// responder instead, so SetEnabled only sticks on them when the menu does
+// not auto-enable its items.
+func (m NSMenuItem) SetEnabled(enabled bool) {
+	checkMainThread("NSMenuItem.SetEnabled")
+	if e := menuEntryFor(uintptr(m.Ptr)); e != nil {
+		e.enabled = enabled
+	}
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setEnabled, enabled)
+}
+
+// SetState sets the checkmark shown next to the item.
+func (m NSMenuItem) SetState(state MenuItemState) {
+	checkMainThread("NSMenuItem.SetState")
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setState, int(state))
+}
+
+func (m NSMenuItem) SetTitle(title string) {
+	checkMainThread("NSMenuItem.SetTitle")
+	nsTitle := NSString_WithUTF8String(title)
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setTitle, uintptr(nsTitle.Ptr))
+}
+
+func (m NSMenuItem) SetHidden(hidden bool) {
+	checkMainThread("NSMenuItem.SetHidden")
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setHidden, hidden)
+}
+
+// SetKeyEquivalent replaces the item's shortcut. An empty key removes it.
+func (m NSMenuItem) SetKeyEquivalent(key string, modifierFlags ...uintptr) {
+	checkMainThread("NSMenuItem.SetKeyEquivalent")
+	var mask uintptr
+	for _, flag := range modifierFlags {
+		mask |= flag
+	}
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setKeyEquivalent, uintptr(NSString_WithUTF8String(key).Ptr))
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setKeyEquivalentModifierMask, mask)
+}
+
+// SetImage shows img next to the item's title. A nil img removes the image.
+func (m NSMenuItem) SetImage(img image.Image) error {
+	checkMainThread("NSMenuItem.SetImage")
+	if img == nil {
+		Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setImage, uintptr(0))
+		return nil
+	}
+	nsImage, err := nsImageFromGoImage(img)
+	if err != nil {
+		return err
+	}
+	Objc_sendMsg[uintptr](uintptr(m.Ptr), Sel_setImage, uintptr(nsImage.Ptr))
+	Objc_sendMsg[uintptr](uintptr(nsImage.Ptr), Sel_release)
+	return nil
+}
+
+func setupMenuTargetClass() {
+	className := "GoMenuTarget"
+	class := objc_allocateClassPair(Class_NSObject, className, 0)
+	if class == 0 {
+		panic("failed to allocate GoMenuTarget class")
+	}
+	if !class_addMethod(class, Sel_menuItemSelected, purego.NewCallback(menuItemSelected), "v@:@") {
+		panic(fmt.Sprintf("failed to add method %s to GoMenuTarget", sel_getName(Sel_menuItemSelected)))
+	}
+	if !class_addMethod(class, Sel_validateMenuItem, purego.NewCallback(validateMenuItem), "B@:@") {
+		panic(fmt.Sprintf("failed to add method %s to GoMenuTarget", sel_getName(Sel_validateMenuItem)))
+	}
+	objc_registerClassPair(class)
+	Class_menuTarget = class
+	menuTarget = Objc_alloc_init(class)
+}
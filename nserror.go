@@ -0,0 +1,96 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"unsafe"
+)
+
+const (
+	nsUnderlyingErrorKey          = "NSUnderlyingError"
+	nsMultipleUnderlyingErrorsKey = "NSMultipleUnderlyingErrorsKey"
+
+	// maxNSErrorDepth bounds how far underlying errors are followed, in case a
+	// userInfo dictionary refers back to its own error.
+	maxNSErrorDepth = 8
+)
+
+// NSErrorFromObject copies a native NSError into a Go *NSError. None of the
+// package's own calls take an NSError** yet; it is for callers making their
+// own objc_msgSend calls. It returns nil when err is nil, so it can be
+// applied directly to an NSError** out-parameter:
+//
+//	var nsErr uintptr
+//	ok := Objc_sendMsg[bool](obj, sel, arg, &nsErr)
+//	if !ok {
+//		return NSErrorFromObject(Object{unsafe.Pointer(nsErr)})
+//	}
+func NSErrorFromObject(err Object) error {
+	if err.Ptr == nil {
+		return nil
+	}
+	return nsErrorFromPtr(uintptr(err.Ptr), 0)
+}
+
+func nsErrorFromPtr(ptr uintptr, depth int) *NSError {
+	pool := NewAutoreleasePool()
+	defer pool.Drain()
+
+	e := &NSError{
+		Domain:      nsStringFromPtr(Objc_sendMsg[uintptr](ptr, Sel_domain)),
+		Code:        int(Objc_sendMsg[int64](ptr, Sel_code)),
+		Description: nsStringFromPtr(Objc_sendMsg[uintptr](ptr, Sel_localizedDescription)),
+	}
+
+	userInfo := Objc_sendMsg[uintptr](ptr, Sel_userInfo)
+	if userInfo == 0 {
+		return e
+	}
+
+	keys := Objc_sendMsg[uintptr](userInfo, Sel_allKeys)
+	count := Objc_sendMsg[uintptr](keys, Sel_count)
+	if count > 0 {
+		e.UserInfo = make(map[string]string, count)
+	}
+	for i := uintptr(0); i < count; i++ {
+		key := Objc_sendMsg[uintptr](keys, Sel_objectAtIndex, i)
+		value := Objc_sendMsg[uintptr](userInfo, Sel_objectForKey, key)
+		e.UserInfo[describeObject(key)] = describeObject(value)
+	}
+
+	if depth >= maxNSErrorDepth {
+		return e
+	}
+
+	underlying := Objc_sendMsg[uintptr](userInfo, Sel_objectForKey, NSString_WithUTF8String(nsUnderlyingErrorKey))
+	if isNSError(underlying) {
+		e.Underlying = append(e.Underlying, nsErrorFromPtr(underlying, depth+1))
+	}
+
+	multiple := Objc_sendMsg[uintptr](userInfo, Sel_objectForKey, NSString_WithUTF8String(nsMultipleUnderlyingErrorsKey))
+	if multiple != 0 {
+		n := Objc_sendMsg[uintptr](multiple, Sel_count)
+		for i := uintptr(0); i < n; i++ {
+			item := Objc_sendMsg[uintptr](multiple, Sel_objectAtIndex, i)
+			if isNSError(item) {
+				e.Underlying = append(e.Underlying, nsErrorFromPtr(item, depth+1))
+			}
+		}
+	}
+	return e
+}
+
+func isNSError(ptr uintptr) bool {
+	return ptr != 0 && Objc_sendMsg[bool](ptr, Sel_isKindOfClass, Class_NSError)
+}
+
+func describeObject(ptr uintptr) string {
+	if ptr == 0 {
+		return ""
+	}
+	return nsStringFromPtr(Objc_sendMsg[uintptr](ptr, Sel_description))
+}
+
+func nsStringFromPtr(ptr uintptr) string {
+	return NSString{Object{unsafe.Pointer(ptr)}}.String()
+}
@@ -0,0 +1,80 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ebitengine/purego"
+)
+
+// RunLoopObserver delivers activity changes of the main run loop to a Go
+// function, which runs on the main thread.
+type RunLoopObserver struct {
+	mu  sync.Mutex
+	ref cfRunLoopObserverRef
+	id  uintptr
+	f   func(RunLoopActivity, time.Time)
+}
+
+var (
+	observerCalloutOnce sync.Once
+	observerCallout     uintptr
+)
+
+// AddRunLoopObserver calls f whenever the main run loop reaches one of the
+// stages in activities while running in one of modes, or in the common modes
+// if none are given. f receives the stage and the time it was reported.
+//
+// order positions the observer among others for the same stage; lower runs
+// first. Core Animation commits its transaction at order 2000000 before
+// waiting, so a frame scheduler that wants its drawing in that commit
+// should use a smaller value.
+func AddRunLoopObserver(activities RunLoopActivity, order int, f func(RunLoopActivity, time.Time), modes ...RunLoopMode) *RunLoopObserver {
+	observerCalloutOnce.Do(func() {
+		observerCallout = purego.NewCallback(runLoopObserverFired)
+	})
+	if len(modes) == 0 {
+		modes = []RunLoopMode{RunLoopCommonModes}
+	}
+
+	pool := NewAutoreleasePool()
+	defer pool.Drain()
+
+	o := &RunLoopObserver{f: f}
+	o.id = StoreGoPointer(o)
+	o.ref = newRunLoopObserver(activities, order, observerCallout, o.id)
+	rl := cfRunLoopGetMain()
+	for _, mode := range modes {
+		cfRunLoopAddObserver(rl, o.ref, runLoopModeString(mode))
+	}
+	return o
+}
+
+// Remove unregisters the observer from every mode. When called off the main
+// thread, a call to f already in progress may still finish afterwards.
+func (o *RunLoopObserver) Remove() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.ref == 0 {
+		return
+	}
+	cfRunLoopObserverInvalidate(o.ref)
+	purego.SyscallN(_CFRelease, uintptr(o.ref))
+	FreeGoPointer(o.id)
+	o.ref, o.id = 0, 0
+}
+
+// runLoopObserverFired is the CFRunLoopObserver callout shared by every
+// observer. info is the Go pointer ID of the RunLoopObserver.
+func runLoopObserverFired(observer, activity, info uintptr) {
+	o, ok := GetGoPointer(info).(*RunLoopObserver)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if p := runRecovered(func() { o.f(RunLoopActivity(activity), now) }); p != nil {
+		handleMainThreadPanic(p)
+	}
+}
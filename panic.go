@@ -0,0 +1,80 @@
+package darwin
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// MainThreadPanic carries a panic recovered from a function that ran on the
+// main thread, along with the stack at the point of the panic. MainThread and
+// Future.Wait re-raise it in the calling goroutine; MainThreadAsync hands it
+// to the handler set with SetMainThreadPanicHandler.
+type MainThreadPanic struct {
+	Value any
+	Stack []byte
+}
+
+func (p *MainThreadPanic) Error() string {
+	return fmt.Sprintf("darwin: panic on main thread: %v\n\n%s", p.Value, p.Stack)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (p *MainThreadPanic) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+var mainThreadPanicHandler atomic.Pointer[func(*MainThreadPanic)]
+
+// SetMainThreadPanicHandler sets the function that receives panics from work
+// queued with MainThreadAsync. It runs on the main thread. Passing nil
+// restores the default, which calls RestoreGlobalState and then re-panics on
+// a new goroutine so the process still terminates with the original stack
+// instead of unwinding through the run loop. A custom handler that lets the
+// process die should call RestoreGlobalState itself.
+func SetMainThreadPanicHandler(h func(*MainThreadPanic)) {
+	if h == nil {
+		mainThreadPanicHandler.Store(nil)
+		return
+	}
+	mainThreadPanicHandler.Store(&h)
+}
+
+func handleMainThreadPanic(p *MainThreadPanic) {
+	if h := mainThreadPanicHandler.Load(); h != nil {
+		(*h)(p)
+		return
+	}
+	// The process is about to die; leave the user's session as we found it.
+	restoreGlobalState()
+	exitOnPanic(p)
+}
+
+// restoreGlobalState and exitOnPanic are the default handler's two steps.
+// Tests replace them to observe a panic without losing the process.
+var (
+	restoreGlobalState = RestoreGlobalState
+	exitOnPanic        = func(p *MainThreadPanic) { go panic(p) }
+)
+
+// runRecovered calls f and returns the panic it raised, if any. Every function
+// the package runs from a native callback goes through it, so no panic
+// unwinds through Objective-C or CoreFoundation frames.
+func runRecovered(f func()) (p *MainThreadPanic) {
+	defer func() {
+		if r := recover(); r != nil {
+			p = &MainThreadPanic{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	f()
+	return nil
+}
+
+// runCallback runs f from a native callback and hands any panic to the
+// main-thread panic handler.
+func runCallback(f func()) {
+	if p := runRecovered(f); p != nil {
+		handleMainThreadPanic(p)
+	}
+}
@@ -0,0 +1,131 @@
+package darwin
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFutureWaitRepanics(t *testing.T) {
+	errBoom := errors.New("boom")
+	fut := MainThreadFuture(func() int {
+		panic(errBoom)
+	})
+
+	defer func() {
+		p, ok := recover().(*MainThreadPanic)
+		if !ok {
+			t.Fatalf("Wait did not panic with *MainThreadPanic")
+		}
+		if !errors.Is(p, errBoom) {
+			t.Errorf("panic value = %v, want %v", p.Value, errBoom)
+		}
+		if len(p.Stack) == 0 {
+			t.Error("panic carries no stack")
+		}
+	}()
+	fut.Wait()
+}
+
+// stopMainLoopForTest stops the main loop as applicationWillTerminate does,
+// and starts a fresh one when the test ends.
+func stopMainLoopForTest(t *testing.T) {
+	stopMainLoop()
+	t.Cleanup(func() {
+		mainQueue = callQueue{}
+		mainLoopStopped = make(chan struct{})
+		mainLoopStoppedOnce = sync.Once{}
+	})
+}
+
+func TestFutureAfterMainLoopStopped(t *testing.T) {
+	stopMainLoopForTest(t)
+	fut := MainThreadFuture(func() int {
+		t.Error("function ran after the main loop stopped")
+		return 1
+	})
+
+	select {
+	case <-fut.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("future was never completed")
+	}
+	if err := fut.Err(); !errors.Is(err, ErrMainLoopStopped) {
+		t.Errorf("Err() = %v, want %v", err, ErrMainLoopStopped)
+	}
+	defer func() {
+		if r := recover(); r != ErrMainLoopStopped {
+			t.Errorf("Wait panicked with %v, want %v", r, ErrMainLoopStopped)
+		}
+	}()
+	fut.Wait()
+}
+
+func TestMainThreadContextPanicsWrapped(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*MainThreadPanic); !ok {
+			t.Error("MainThreadContext did not panic with *MainThreadPanic")
+		}
+	}()
+	MainThreadContext(context.Background(), func() { panic("boom") })
+}
+
+func TestMainThreadContextAfterMainLoopStopped(t *testing.T) {
+	stopMainLoopForTest(t)
+	err := MainThreadContext(context.Background(), func() {
+		t.Error("function ran after the main loop stopped")
+	})
+	if !errors.Is(err, ErrMainLoopStopped) {
+		t.Errorf("MainThreadContext() = %v, want %v", err, ErrMainLoopStopped)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := MainThreadContext(ctx, func() {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("MainThreadContext(cancelled) = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestMainThreadAsyncPanicHandler(t *testing.T) {
+	got := make(chan *MainThreadPanic, 1)
+	SetMainThreadPanicHandler(func(p *MainThreadPanic) { got <- p })
+	defer SetMainThreadPanicHandler(nil)
+
+	MainThreadAsync(func() { panic("async") })
+
+	select {
+	case p := <-got:
+		if p.Value != "async" {
+			t.Errorf("handler got %v, want %q", p.Value, "async")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("panic handler was not called")
+	}
+}
+
+func TestCallbackPanicRestoresGlobalState(t *testing.T) {
+	SetMainThreadPanicHandler(nil)
+	restore, exit := restoreGlobalState, exitOnPanic
+	defer func() { restoreGlobalState, exitOnPanic = restore, exit }()
+
+	tracked := true
+	var got *MainThreadPanic
+	restoreGlobalState = func() {
+		if got != nil {
+			t.Error("state restored after the panic was re-raised")
+		}
+		tracked = false
+	}
+	exitOnPanic = func(p *MainThreadPanic) { got = p }
+
+	runCallback(func() { panic("callback") })
+
+	if tracked {
+		t.Error("tracked state was not restored")
+	}
+	if got == nil || got.Value != "callback" {
+		t.Errorf("re-raised %v, want panic %q", got, "callback")
+	}
+}
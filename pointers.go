@@ -0,0 +1,54 @@
+package darwin
+
+import (
+	"sync"
+	"unsafe"
+)
+
+func NewCString(s string) *byte {
+	b := make([]byte, len(s)+1)
+	copy(b, s)
+	return &b[0]
+}
+
+func GoString(s uintptr) string {
+	if s == 0 {
+		return ""
+	}
+	// s points into C memory, so reinterpret it rather than converting.
+	p := *(*unsafe.Pointer)(unsafe.Pointer(&s))
+	var l int
+	for *(*byte)(unsafe.Add(p, l)) != 0 {
+		l++
+	}
+	return string(unsafe.Slice((*byte)(p), l))
+}
+
+// goPointers is a map used to associate a simple integer ID with a Go interface{}.
+// This allows us to safely pass a reference to a Go object into the C/Objective-C world.
+var (
+	goPointers    = make(map[uintptr]any)
+	goPointersMtx sync.RWMutex
+	goPointerID   uintptr
+)
+
+func StoreGoPointer(v any) uintptr {
+	goPointersMtx.Lock()
+	defer goPointersMtx.Unlock()
+	goPointerID++
+	ptr := goPointerID
+	goPointers[ptr] = v
+	return ptr
+}
+
+func GetGoPointer(ptr uintptr) any {
+	goPointersMtx.RLock()
+	defer goPointersMtx.RUnlock()
+	return goPointers[ptr]
+}
+
+func FreeGoPointer(ptr uintptr) {
+	goPointersMtx.Lock()
+	defer goPointersMtx.Unlock()
+	delete(goPointers, ptr)
+}
@@ -0,0 +1,62 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"sync"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	osVersionOnce sync.Once
+	osVersion     OSVersion
+)
+
+// OperatingSystemVersion returns NSProcessInfo.operatingSystemVersion. The
+// value is read once and cached.
+func OperatingSystemVersion() OSVersion {
+	osVersionOnce.Do(func() {
+		info := Objc_sendMsg[uintptr](Class_NSProcessInfo, Sel_processInfo)
+		if info == 0 {
+			return
+		}
+		// NSOperatingSystemVersion is three NSIntegers.
+		type nsOperatingSystemVersion struct{ Major, Minor, Patch int64 }
+		var operatingSystemVersion func(uintptr, Selector) nsOperatingSystemVersion
+		purego.RegisterLibFunc(&operatingSystemVersion, libobjc, "objc_msgSend")
+		v := operatingSystemVersion(info, Sel_operatingSystemVersion)
+		osVersion = OSVersion{int(v.Major), int(v.Minor), int(v.Patch)}
+	})
+	return osVersion
+}
+
+// IsOSAtLeast reports whether the running macOS is v or newer.
+func IsOSAtLeast(v OSVersion) bool {
+	return OperatingSystemVersion().AtLeast(v)
+}
+
+// RequireOS returns an *UnavailableError naming op when the running macOS is
+// older than v. Bindings call it before using an API introduced in v.
+func RequireOS(op string, v OSVersion) error {
+	return checkOSVersion(op, OperatingSystemVersion(), v)
+}
+
+// RespondsToSelector reports whether obj implements sel. A nil obj never
+// responds.
+func RespondsToSelector(obj uintptr, sel Selector) bool {
+	if obj == 0 {
+		return false
+	}
+	return Objc_sendMsg[bool](obj, Sel_respondsToSelector, sel)
+}
+
+// RequireSelector returns an *UnavailableError naming op when obj does not
+// implement sel, so that a missing method is reported instead of raising an
+// unrecognized selector exception.
+func RequireSelector(op string, obj uintptr, sel Selector) error {
+	if RespondsToSelector(obj, sel) {
+		return nil
+	}
+	return &UnavailableError{Op: op, Selector: sel_getName(sel)}
+}
@@ -0,0 +1,100 @@
+package darwin
+
+import (
+	"sync"
+)
+
+// Work for the main thread is appended to mainQueue. Once the application
+// has begun terminating, stopMainLoop closes mainLoopStopped and the queue.
+var (
+	mainQueue           callQueue
+	mainLoopStopped     = make(chan struct{})
+	mainLoopStoppedOnce sync.Once
+)
+
+// stopMainLoop is called from applicationWillTerminate. Pending calls are
+// rejected, waiting callers get ErrMainLoopStopped, and later calls are
+// rejected without being queued.
+func stopMainLoop() {
+	mainLoopStoppedOnce.Do(func() {
+		close(mainLoopStopped)
+		mainQueue.close()
+	})
+}
+
+// callQueue is the Go side of main-thread dispatch. Producers append under a
+// mutex; the main thread takes the whole batch at once. Only the push that
+// finds the queue idle asks for a wake-up, so a burst of calls costs one
+// signal regardless of its size.
+type callQueue struct {
+	mu       sync.Mutex
+	pending  []queuedCall
+	spare    []queuedCall
+	signaled bool
+	closed   bool
+}
+
+// queuedCall is one entry of a callQueue. If reject is not nil, it is called
+// in place of run when the queue drops the call, so whoever waits on run
+// still hears back.
+type queuedCall struct {
+	run, reject func()
+}
+
+// push appends f and reports whether the consumer must be woken. Once the
+// queue is closed f is dropped, reject is called if it is not nil, and push
+// reports false.
+func (q *callQueue) push(f, reject func()) bool {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		if reject != nil {
+			reject()
+		}
+		return false
+	}
+	q.pending = append(q.pending, queuedCall{run: f, reject: reject})
+	wake := !q.signaled
+	q.signaled = true
+	q.mu.Unlock()
+	return wake
+}
+
+// close stops the queue accepting calls and drops those not yet taken, so
+// nothing they capture stays reachable. The reject function of each dropped
+// call runs before close returns.
+func (q *callQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	dropped := q.pending
+	q.pending = nil
+	q.spare = nil
+	q.mu.Unlock()
+	for _, c := range dropped {
+		if c.reject != nil {
+			c.reject()
+		}
+	}
+}
+
+// take removes and returns everything queued so far. Calls pushed while the
+// batch runs land in the next one and signal again.
+func (q *callQueue) take() []queuedCall {
+	q.mu.Lock()
+	batch := q.pending
+	q.pending = q.spare[:0]
+	q.spare = nil
+	q.signaled = false
+	q.mu.Unlock()
+	return batch
+}
+
+// recycle hands a drained batch back so its backing array can be reused.
+func (q *callQueue) recycle(batch []queuedCall) {
+	clear(batch)
+	q.mu.Lock()
+	if q.spare == nil {
+		q.spare = batch[:0]
+	}
+	q.mu.Unlock()
+}
@@ -0,0 +1,84 @@
+package darwin
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCallQueueCoalescesWakeups(t *testing.T) {
+	var q callQueue
+	var got []int
+	wakes := 0
+	for i := range 5 {
+		if q.push(func() { got = append(got, i) }, nil) {
+			wakes++
+		}
+	}
+	if wakes != 1 {
+		t.Fatalf("5 pushes to an idle queue woke %d times, want 1", wakes)
+	}
+
+	batch := q.take()
+	for _, c := range batch {
+		c.run()
+	}
+	q.recycle(batch)
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("batch ran out of order: %v", got)
+		}
+	}
+
+	if !q.push(func() {}, nil) {
+		t.Fatal("push after take did not request a wake-up")
+	}
+	if n := len(q.take()); n != 1 {
+		t.Fatalf("second batch has %d calls, want 1", n)
+	}
+}
+
+func TestCallQueueClose(t *testing.T) {
+	var q callQueue
+	q.push(func() {}, nil)
+	q.close()
+	if n := len(q.take()); n != 0 {
+		t.Fatalf("closed queue kept %d pending calls", n)
+	}
+	if q.push(func() {}, nil) {
+		t.Fatal("closed queue requested a wake-up")
+	}
+	if n := len(q.take()); n != 0 {
+		t.Fatalf("closed queue accepted %d calls", n)
+	}
+}
+
+func TestCallQueueCloseRejects(t *testing.T) {
+	var q callQueue
+	var rejected []int
+	for i := range 3 {
+		q.push(func() { t.Errorf("dropped call %d ran", i) }, func() { rejected = append(rejected, i) })
+	}
+	q.close()
+	if len(rejected) != 3 || rejected[0] != 0 || rejected[2] != 2 {
+		t.Fatalf("close rejected %v, want [0 1 2]", rejected)
+	}
+	q.push(func() {}, func() { rejected = append(rejected, 3) })
+	if len(rejected) != 4 {
+		t.Fatal("push to a closed queue did not reject the call")
+	}
+}
+
+func BenchmarkCallQueue(b *testing.B) {
+	var q callQueue
+	var mu sync.Mutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if q.push(func() {}, nil) {
+				mu.Lock()
+				batch := q.take()
+				q.recycle(batch)
+				mu.Unlock()
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+var (
+	_CFRunLoopGetMain,
+	_CFRunLoopRun,
+	_CFRunLoopStop,
+	_CFRunLoopWakeUp,
+	_CFRunLoopSourceCreate,
+	_CFRunLoopAddSource,
+	_CFRunLoopSourceSignal,
+	_CFAbsoluteTimeGetCurrent,
+	_CFRunLoopTimerCreate,
+	_CFRunLoopAddTimer,
+	_CFRunLoopTimerInvalidate,
+	_CFRunLoopTimerSetTolerance,
+	_CFRunLoopObserverCreate,
+	_CFRunLoopAddObserver,
+	_CFRunLoopObserverInvalidate uintptr
+)
+
+// kCFRunLoopCommonModes covers the default, modal panel and event tracking
+// modes, so sources added with it keep firing during live resize and menu
+// tracking.
+var kCFRunLoopCommonModes uintptr
+
+type cfRunLoopSourceRef uintptr
+type cfRunLoopTimerRef uintptr
+type cfRunLoopObserverRef uintptr
+
+// cfRunLoopSourceContext is CFRunLoopSourceContext (version 0).
+type cfRunLoopSourceContext struct {
+	version         int
+	info            uintptr
+	retain          uintptr
+	release         uintptr
+	copyDescription uintptr
+	equal           uintptr
+	hash            uintptr
+	schedule        uintptr
+	cancel          uintptr
+	perform         uintptr
+}
+
+func cfRunLoopGetMain() CFRunLoopRef {
+	ret, _, _ := purego.SyscallN(_CFRunLoopGetMain)
+	return CFRunLoopRef(ret)
+}
+
+// cfRunLoopRun runs the current thread's run loop in the default mode until
+// it is stopped or has no sources left.
+func cfRunLoopRun() {
+	purego.SyscallN(_CFRunLoopRun)
+}
+
+func cfRunLoopStop(rl CFRunLoopRef) {
+	purego.SyscallN(_CFRunLoopStop, uintptr(rl))
+}
+
+func cfRunLoopWakeUp(rl CFRunLoopRef) {
+	purego.SyscallN(_CFRunLoopWakeUp, uintptr(rl))
+}
+
+// newRunLoopSource creates a version 0 source whose perform callback is the
+// result of purego.NewCallback. The context is copied by CoreFoundation.
+func newRunLoopSource(order int, perform uintptr) cfRunLoopSourceRef {
+	ctx := cfRunLoopSourceContext{perform: perform}
+	ret, _, _ := purego.SyscallN(_CFRunLoopSourceCreate, 0, uintptr(order), uintptr(unsafe.Pointer(&ctx)))
+	return cfRunLoopSourceRef(ret)
+}
+
+func cfRunLoopAddSource(rl CFRunLoopRef, source cfRunLoopSourceRef, mode uintptr) {
+	purego.SyscallN(_CFRunLoopAddSource, uintptr(rl), uintptr(source), mode)
+}
+
+func cfRunLoopSourceSignal(source cfRunLoopSourceRef) {
+	purego.SyscallN(_CFRunLoopSourceSignal, uintptr(source))
+}
+
+// cfRunLoopTimerContext is CFRunLoopTimerContext.
+type cfRunLoopTimerContext struct {
+	version         int
+	info            uintptr
+	retain          uintptr
+	release         uintptr
+	copyDescription uintptr
+}
+
+// cfAbsoluteTimeGetCurrent returns the current time in seconds since
+// 2001-01-01, the reference date of CFRunLoopTimer fire dates.
+func cfAbsoluteTimeGetCurrent() float64 {
+	var now func() float64
+	purego.RegisterFunc(&now, _CFAbsoluteTimeGetCurrent)
+	return now()
+}
+
+// newRunLoopTimer creates a timer that first fires at fireDate and then every
+// interval seconds, or once if interval is zero. callout is the result of
+// purego.NewCallback and receives info.
+func newRunLoopTimer(fireDate, interval float64, callout, info uintptr) cfRunLoopTimerRef {
+	var create func(allocator uintptr, fireDate, interval float64, flags uint64, order int, callout uintptr, context *cfRunLoopTimerContext) uintptr
+	purego.RegisterFunc(&create, _CFRunLoopTimerCreate)
+	ctx := cfRunLoopTimerContext{info: info}
+	return cfRunLoopTimerRef(create(0, fireDate, interval, 0, 0, callout, &ctx))
+}
+
+func cfRunLoopAddTimer(rl CFRunLoopRef, timer cfRunLoopTimerRef, mode uintptr) {
+	purego.SyscallN(_CFRunLoopAddTimer, uintptr(rl), uintptr(timer), mode)
+}
+
+func cfRunLoopTimerInvalidate(timer cfRunLoopTimerRef) {
+	purego.SyscallN(_CFRunLoopTimerInvalidate, uintptr(timer))
+}
+
+func cfRunLoopTimerSetTolerance(timer cfRunLoopTimerRef, tolerance float64) {
+	var setTolerance func(timer uintptr, tolerance float64)
+	purego.RegisterFunc(&setTolerance, _CFRunLoopTimerSetTolerance)
+	setTolerance(uintptr(timer), tolerance)
+}
+
+// cfRunLoopObserverContext is CFRunLoopObserverContext.
+type cfRunLoopObserverContext struct {
+	version         int
+	info            uintptr
+	retain          uintptr
+	release         uintptr
+	copyDescription uintptr
+}
+
+// newRunLoopObserver creates a repeating observer for activities. callout is
+// the result of purego.NewCallback and receives info.
+func newRunLoopObserver(activities RunLoopActivity, order int, callout, info uintptr) cfRunLoopObserverRef {
+	ctx := cfRunLoopObserverContext{info: info}
+	ret, _, _ := purego.SyscallN(_CFRunLoopObserverCreate, 0, uintptr(activities), 1, uintptr(order), callout, uintptr(unsafe.Pointer(&ctx)))
+	return cfRunLoopObserverRef(ret)
+}
+
+func cfRunLoopAddObserver(rl CFRunLoopRef, observer cfRunLoopObserverRef, mode uintptr) {
+	purego.SyscallN(_CFRunLoopAddObserver, uintptr(rl), uintptr(observer), mode)
+}
+
+func cfRunLoopObserverInvalidate(observer cfRunLoopObserverRef) {
+	purego.SyscallN(_CFRunLoopObserverInvalidate, uintptr(observer))
+}
+
+// runLoopModeString returns the CFStringRef for mode. The common modes pseudo
+// mode uses the framework constant; other modes are matched by name.
+func runLoopModeString(mode RunLoopMode) uintptr {
+	if mode == RunLoopCommonModes {
+		return kCFRunLoopCommonModes
+	}
+	return uintptr(NSString_WithUTF8String(string(mode)).Ptr)
+}
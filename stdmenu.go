@@ -0,0 +1,82 @@
+package darwin
+
+// MenuAnchor names a place in the menu built by StandardMenu where callers
+// can add their own items.
+type MenuAnchor int
+
+const (
+	// AnchorAppAbout follows "About <app>", for items such as "Check for
+	// Updates…".
+	AnchorAppAbout MenuAnchor = iota
+	// AnchorAppSettings is the section after About, for "Settings…" and
+	// similar items.
+	AnchorAppSettings
+	// AnchorEdit ends the Edit menu, after Select All.
+	AnchorEdit
+	// AnchorWindow follows Minimize and Zoom in the Window menu.
+	AnchorWindow
+	// AnchorBeforeEdit, AnchorBeforeWindow and AnchorAfterWindow place
+	// top-level menus in the menu bar, such as File, View and Help. Items at
+	// these anchors should have a Submenu.
+	AnchorBeforeEdit
+	AnchorBeforeWindow
+	AnchorAfterWindow
+)
+
+// StandardMenu returns the main menu every Mac application is expected to
+// have: the application menu with About, Services, Hide, Hide Others, Show
+// All and Quit titled for appName, an Edit menu and a Window menu, with the
+// system shortcuts. insert adds items at the given anchors; a section that
+// gets items is set off by separators. It may be called before Initialize:
+// the standard items name their actions, which are looked up when the menu
+// is built.
+func StandardMenu(appName string, insert map[MenuAnchor][]MenuItem) *Menu {
+	section := func(anchor MenuAnchor) []MenuItem {
+		if len(insert[anchor]) == 0 {
+			return nil
+		}
+		return append([]MenuItem{{IsSeparator: true}}, insert[anchor]...)
+	}
+	sep := MenuItem{IsSeparator: true}
+
+	var appItems []MenuItem
+	appItems = append(appItems, MenuItem{Title: "About " + appName, actionName: "orderFrontStandardAboutPanel:"})
+	appItems = append(appItems, insert[AnchorAppAbout]...)
+	appItems = append(appItems, section(AnchorAppSettings)...)
+	appItems = append(appItems,
+		sep,
+		MenuItem{Title: "Services", Submenu: &Menu{Title: "Services", Role: MenuRoleServices}},
+		sep,
+		MenuItem{Title: "Hide " + appName, actionName: "hide:", Accelerator: "Cmd+H"},
+		MenuItem{Title: "Hide Others", actionName: "hideOtherApplications:", Accelerator: "Cmd+Alt+H"},
+		MenuItem{Title: "Show All", actionName: "unhideAllApplications:"},
+		sep,
+		MenuItem{Title: "Quit " + appName, actionName: "terminate:", Accelerator: "Cmd+Q"},
+	)
+
+	editItems := []MenuItem{
+		{Title: "Undo", actionName: "undo:", Accelerator: "Cmd+Z"},
+		{Title: "Redo", actionName: "redo:", Accelerator: "Cmd+Shift+Z"},
+		sep,
+		{Title: "Cut", actionName: "cut:", Accelerator: "Cmd+X"},
+		{Title: "Copy", actionName: "copy:", Accelerator: "Cmd+C"},
+		{Title: "Paste", actionName: "paste:", Accelerator: "Cmd+V"},
+		{Title: "Select All", actionName: "selectAll:", Accelerator: "Cmd+A"},
+	}
+	editItems = append(editItems, section(AnchorEdit)...)
+
+	windowItems := []MenuItem{
+		{Title: "Minimize", actionName: "performMiniaturize:", Accelerator: "Cmd+M"},
+		{Title: "Zoom", actionName: "performZoom:"},
+	}
+	windowItems = append(windowItems, section(AnchorWindow)...)
+	windowItems = append(windowItems, sep, MenuItem{Title: "Bring All to Front", actionName: "arrangeInFront:"})
+
+	items := []MenuItem{{Title: appName, Submenu: &Menu{Title: appName, Items: appItems}}}
+	items = append(items, insert[AnchorBeforeEdit]...)
+	items = append(items, MenuItem{Title: "Edit", Submenu: &Menu{Title: "Edit", Items: editItems}})
+	items = append(items, insert[AnchorBeforeWindow]...)
+	items = append(items, MenuItem{Title: "Window", Submenu: &Menu{Title: "Window", Items: windowItems, Role: MenuRoleWindows}})
+	items = append(items, insert[AnchorAfterWindow]...)
+	return &Menu{Items: items}
+}
@@ -0,0 +1,81 @@
+package darwin
+
+import (
+	"slices"
+	"testing"
+)
+
+// menuTitles lists the titles of items, with "-" for a separator.
+func menuTitles(items []MenuItem) []string {
+	var titles []string
+	for _, item := range items {
+		if item.IsSeparator {
+			titles = append(titles, "-")
+			continue
+		}
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
+
+func TestStandardMenu(t *testing.T) {
+	item := func(title string) []MenuItem { return []MenuItem{{Title: title}} }
+	menu := StandardMenu("App", map[MenuAnchor][]MenuItem{
+		AnchorAppAbout:     item("Check for Updates…"),
+		AnchorAppSettings:  item("Settings…"),
+		AnchorEdit:         item("Find"),
+		AnchorWindow:       item("Tile"),
+		AnchorBeforeEdit:   item("File"),
+		AnchorBeforeWindow: item("View"),
+		AnchorAfterWindow:  item("Help"),
+	})
+
+	if got, want := menuTitles(menu.Items), []string{"App", "File", "Edit", "View", "Window", "Help"}; !slices.Equal(got, want) {
+		t.Fatalf("menu bar = %q, want %q", got, want)
+	}
+	tests := []struct {
+		index int
+		items []string
+		role  MenuRole
+	}{
+		{0, []string{"About App", "Check for Updates…", "-", "Settings…", "-", "Services", "-", "Hide App", "Hide Others", "Show All", "-", "Quit App"}, MenuRoleNone},
+		{2, []string{"Undo", "Redo", "-", "Cut", "Copy", "Paste", "Select All", "-", "Find"}, MenuRoleNone},
+		{4, []string{"Minimize", "Zoom", "-", "Tile", "-", "Bring All to Front"}, MenuRoleWindows},
+	}
+	for _, tt := range tests {
+		top := menu.Items[tt.index]
+		sub := top.Submenu
+		if got := menuTitles(sub.Items); !slices.Equal(got, tt.items) {
+			t.Errorf("%s menu = %q, want %q", top.Title, got, tt.items)
+		}
+		if sub.Role != tt.role {
+			t.Errorf("%s menu role = %v, want %v", top.Title, sub.Role, tt.role)
+		}
+	}
+
+	services := menu.Items[0].Submenu.Items[5].Submenu
+	if services == nil || services.Role != MenuRoleServices {
+		t.Errorf("Services submenu = %+v, want role MenuRoleServices", services)
+	}
+	if err := menu.ValidateAccelerators(); err != nil {
+		t.Errorf("ValidateAccelerators() = %v", err)
+	}
+}
+
+func TestStandardMenuEmptyAnchors(t *testing.T) {
+	menu := StandardMenu("App", nil)
+	if got, want := menuTitles(menu.Items), []string{"App", "Edit", "Window"}; !slices.Equal(got, want) {
+		t.Fatalf("menu bar = %q, want %q", got, want)
+	}
+	want := []string{"About App", "-", "Services", "-", "Hide App", "Hide Others", "Show All", "-", "Quit App"}
+	if got := menuTitles(menu.Items[0].Submenu.Items); !slices.Equal(got, want) {
+		t.Errorf("App menu = %q, want %q", got, want)
+	}
+	for _, sub := range menu.Items {
+		for _, item := range sub.Submenu.Items {
+			if !item.IsSeparator && item.Submenu == nil && item.actionName == "" {
+				t.Errorf("%s > %s has no action", sub.Title, item.Title)
+			}
+		}
+	}
+}
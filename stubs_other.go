@@ -0,0 +1,583 @@
+//go:build !darwin
+
+package darwin
+
+import (
+	"context"
+	"image"
+	"time"
+	"unsafe"
+)
+
+// This file provides the public API on platforms without a Cocoa runtime.
+// Every call that can report an error returns an *UnsupportedError matching
+// ErrUnsupported; the rest are no-ops that return zero values.
+
+func Initialize() error {
+	return unsupported("Initialize")
+}
+
+// Application
+
+func SetupApplication(appName string, delegate uintptr, menu ApplicationMenu) (Object, error) {
+	return Object{}, unsupported("SetupApplication")
+}
+
+func SetupApplicationMenu(appName string, delegate uintptr, menu *Menu) (Object, error) {
+	return Object{}, unsupported("SetupApplicationMenu")
+}
+
+func SetMainMenu(menu *Menu) error {
+	return unsupported("SetMainMenu")
+}
+
+func NSApp() (Object, error) {
+	return Object{}, unsupported("NSApp")
+}
+
+func RunApplication(app Object) {}
+
+func SetMenuCommandHandler(f func(command int)) {}
+
+func PopUpContextMenu(view Object, menu *Menu, location NSPoint) (MenuItem, bool) {
+	return MenuItem{}, false
+}
+
+func (m NSMenuItem) SetEnabled(enabled bool) {}
+
+func (m NSMenuItem) SetState(state MenuItemState) {}
+
+func (m NSMenuItem) SetTitle(title string) {}
+
+func (m NSMenuItem) SetHidden(hidden bool) {}
+
+func (m NSMenuItem) SetKeyEquivalent(key string, modifierFlags ...uintptr) {}
+
+func (m NSMenuItem) SetImage(img image.Image) error {
+	return unsupported("NSMenuItem.SetImage")
+}
+
+func RunApplicationContext(ctx context.Context, app Object) error {
+	return unsupported("RunApplicationContext")
+}
+
+func StopApplication() {}
+
+func Terminate() {}
+
+// Main runs run on the calling goroutine.
+func Main(run func()) {
+	run()
+}
+
+func ActivateIgnoringOtherApps(app Object) {}
+
+func Activate(app Object) {}
+
+func SetAppDelegateCallback(f func()) {}
+
+func SetAppTerminationCallback(f func()) {}
+
+// Threading
+
+// MainThread runs f on the calling goroutine. There is no AppKit main thread
+// to dispatch to, and any package call made from f reports ErrUnsupported.
+func MainThread(f func()) {
+	_ = MainThreadContext(context.Background(), f)
+}
+
+// MainThreadContext runs f on the calling goroutine unless ctx is already done
+// or the main loop has been stopped. A panic in f is raised again as a
+// *MainThreadPanic.
+func MainThreadContext(ctx context.Context, f func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-mainLoopStopped:
+		return ErrMainLoopStopped
+	default:
+	}
+	if p := runRecovered(f); p != nil {
+		panic(p)
+	}
+	return nil
+}
+
+// MainThreadAsync runs f on the calling goroutine before returning. A panic in
+// f is passed to the handler set with SetMainThreadPanicHandler.
+func MainThreadAsync(f func()) {
+	dispatch(func() { runCallback(f) }, nil)
+}
+
+// dispatch runs f on the calling goroutine, or reject, if not nil, once the
+// main loop has been stopped.
+func dispatch(f, reject func()) {
+	select {
+	case <-mainLoopStopped:
+		if reject != nil {
+			reject()
+		}
+	default:
+		f()
+	}
+}
+
+// Timers
+
+type Timer struct{}
+
+type Ticker struct{}
+
+func AfterFunc(d time.Duration, f func()) *Timer {
+	return &Timer{}
+}
+
+func (tm *Timer) Stop() bool {
+	return false
+}
+
+func (tm *Timer) Reset(d time.Duration) bool {
+	return false
+}
+
+func (tm *Timer) SetTolerance(d time.Duration) {}
+
+func NewTicker(d time.Duration, f func()) *Ticker {
+	if d <= 0 {
+		panic("darwin: non-positive interval for NewTicker")
+	}
+	return &Ticker{}
+}
+
+func (tk *Ticker) Stop() {}
+
+func (tk *Ticker) Reset(d time.Duration) {}
+
+func (tk *Ticker) SetTolerance(d time.Duration) {}
+
+// Event loop
+
+func PollEvents() {}
+
+func WaitEvents(timeout time.Duration) {}
+
+func PostEmptyEvent() {}
+
+// Run loop observers
+
+type RunLoopObserver struct{}
+
+func AddRunLoopObserver(activities RunLoopActivity, order int, f func(RunLoopActivity, time.Time), modes ...RunLoopMode) *RunLoopObserver {
+	return &RunLoopObserver{}
+}
+
+func (o *RunLoopObserver) Remove() {}
+
+// Objective-C runtime
+
+func Objc_sendMsg[R any](receiver uintptr, selector Selector, args ...any) R {
+	var zero R
+	return zero
+}
+
+func Objc_alloc_init(class uintptr) uintptr {
+	return 0
+}
+
+func Sel_getUid(name string) Selector {
+	return 0
+}
+
+func NewAutoreleasePool() NSAutoreleasePool {
+	return NSAutoreleasePool{}
+}
+
+func (p NSAutoreleasePool) Drain() {}
+
+func (o Object) Retain() {}
+
+func (o Object) Release() {}
+
+func (o Object) Autorelease() {}
+
+// Errors
+
+func NSErrorFromObject(err Object) error {
+	return nil
+}
+
+// Availability
+
+func OperatingSystemVersion() OSVersion {
+	return OSVersion{}
+}
+
+func IsOSAtLeast(v OSVersion) bool {
+	return false
+}
+
+func RequireOS(op string, v OSVersion) error {
+	return unsupported(op)
+}
+
+func RespondsToSelector(obj uintptr, sel Selector) bool {
+	return false
+}
+
+func RequireSelector(op string, obj uintptr, sel Selector) error {
+	return unsupported(op)
+}
+
+// Weak references
+
+func NewWeak[T ~struct{ Object }](obj T) *Weak[T] {
+	return &Weak[T]{}
+}
+
+func (w *Weak[T]) Load() (T, bool) {
+	var zero T
+	return zero, false
+}
+
+func (w *Weak[T]) Do(f func(T)) bool {
+	return false
+}
+
+func (w *Weak[T]) Destroy() {}
+
+// Strings and clipboard
+
+func NSString_WithUTF8String(s string) NSString {
+	return NSString{}
+}
+
+func (s NSString) String() string {
+	return ""
+}
+
+func GetClipboardString() (string, error) {
+	return "", unsupported("GetClipboardString")
+}
+
+func SetClipboardString(value string) error {
+	return unsupported("SetClipboardString")
+}
+
+// Windows
+
+func NewSplashWindow(img image.Image) (NSWindow, error) {
+	return NSWindow{}, unsupported("NewSplashWindow")
+}
+
+func NewNSWindow(title string, width, height int) (NSWindow, error) {
+	return NSWindow{}, unsupported("NewNSWindow")
+}
+
+func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWindow, NSOpenGLView, NSOpenGLContext, error) {
+	return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, unsupported("NewNSWindowOpenGL")
+}
+
+func NewCustomOpenGLView(frame NSRect, pixelFormat NSOpenGLPixelFormat) (NSOpenGLView, error) {
+	return NSOpenGLView{}, unsupported("NewCustomOpenGLView")
+}
+
+func (w NSWindow) SetTitle(title string) {}
+
+func (w NSWindow) SetBackgroundColor(r, g, b, a float64) {}
+
+func (w NSWindow) SetTitlebarAppearsTransparent(transparent bool) {}
+
+func (w NSWindow) SetTitleVisibility(visible bool) {}
+
+func (w NSWindow) SetWindowLevel(level int) {}
+
+func (w NSWindow) ContentSize() (width, height int, scale float64) {
+	return 0, 0, 1.0
+}
+
+func (w NSWindow) Frame() NSRect {
+	return NSRect{}
+}
+
+func (s NSScreen) Frame() NSRect {
+	return NSRect{}
+}
+
+func SetContentView(w NSWindow, v Object) {}
+
+func SetOpenGLContext(v NSOpenGLView, ctx NSOpenGLContext) {}
+
+func SetDelegateAndLinkGo(w NSWindow, delegateAsView NSOpenGLView, goWindow any) {}
+
+func MakeKeyAndOrderFront(w NSWindow) {}
+
+func CloseWindow(w NSWindow) {}
+
+func MakeCurrentOpenGLContext(ctx NSOpenGLContext) {}
+
+func FlushBuffer(ctx NSOpenGLContext) {}
+
+func IsKeyWindow(w NSWindow) bool {
+	return false
+}
+
+func SetWindowFrameTopLeftPoint(w NSWindow, x, y int) {}
+
+func WindowFrameTopLeftPoint(w NSWindow) (int, int) {
+	return 0, 0
+}
+
+func IsWindowFullscreen(w NSWindow) bool {
+	return false
+}
+
+func ToggleWindowFullScreen(w NSWindow) {}
+
+func SetCursor(cursor NSCursor) {}
+
+func SetCursorMode(mode DarwinCursorMode) {}
+
+func WarpMouseCursorToPoint(x, y float64) {}
+
+func CreateCustomCursor(img image.Image, hotX, hotY int) (NSCursor, error) {
+	return NSCursor{}, unsupported("CreateCustomCursor")
+}
+
+func SetApplicationIconImageFromImage(img image.Image) error {
+	return unsupported("SetApplicationIconImageFromImage")
+}
+
+// Events
+
+func EventLocationInWindow(event NSEvent) (float64, float64) {
+	return 0, 0
+}
+
+func EventScrollingDeltaX(event NSEvent) float64 {
+	return 0
+}
+
+func EventScrollingDeltaY(event NSEvent) float64 {
+	return 0
+}
+
+func EventButtonNumber(event NSEvent) int {
+	return 0
+}
+
+func EventClickCount(event NSEvent) int {
+	return 0
+}
+
+func EventKeyCode(event NSEvent) int {
+	return 0
+}
+
+func EventModifierFlags(event NSEvent) uintptr {
+	return 0
+}
+
+func EventCharacters(event NSEvent) string {
+	return ""
+}
+
+func EventFilePathsFromPasteboard(pasteboard uintptr) []string {
+	return nil
+}
+
+func EventMagnification(event NSEvent) float64 {
+	return 0
+}
+
+func EventRotation(event NSEvent) float64 {
+	return 0
+}
+
+func EventPhase(event NSEvent) uintptr {
+	return 0
+}
+
+func EventTranslationX(event NSEvent) float64 {
+	return 0
+}
+
+func EventTranslationY(event NSEvent) float64 {
+	return 0
+}
+
+// Joysticks
+
+func SetupJoysticks() {}
+
+func IsJoystickPresent(joy int) bool {
+	return false
+}
+
+func GetJoystickName(joy int) string {
+	return ""
+}
+
+func GetJoystickAxes(joy int) ([]float32, error) {
+	return nil, unsupported("GetJoystickAxes")
+}
+
+func GetJoystickButtons(joy int) ([]byte, error) {
+	return nil, unsupported("GetJoystickButtons")
+}
+
+func GetJoystickHats(joy int) ([]byte, error) {
+	return nil, unsupported("GetJoystickHats")
+}
+
+// CoreVideo
+
+const kCVReturnUnsupported = -6663
+
+func CVDisplayLinkCreateWithCGDisplay(displayID CGDirectDisplayID, displayLinkOut *CVDisplayLinkRef) int32 {
+	return kCVReturnUnsupported
+}
+
+func CVDisplayLinkSetOutputCallback(displayLink CVDisplayLinkRef, callback uintptr, userInfo unsafe.Pointer) int32 {
+	return kCVReturnUnsupported
+}
+
+func CVDisplayLinkSetCurrentCGDisplay(displayLink CVDisplayLinkRef, displayID CGDirectDisplayID) int32 {
+	return kCVReturnUnsupported
+}
+
+func CVDisplayLinkStart(displayLink CVDisplayLinkRef) int32 {
+	return kCVReturnUnsupported
+}
+
+func CVDisplayLinkStop(displayLink CVDisplayLinkRef) int32 {
+	return kCVReturnUnsupported
+}
+
+func CVDisplayLinkRelease(displayLink CVDisplayLinkRef) {}
+
+// Dispatch
+
+func MainQueue() DispatchQueue {
+	return 0
+}
+
+func GlobalQueue(qos QoSClass) DispatchQueue {
+	return 0
+}
+
+func NewSerialQueue(label string, qos QoSClass) DispatchQueue {
+	return 0
+}
+
+func NewConcurrentQueue(label string, qos QoSClass) DispatchQueue {
+	return 0
+}
+
+func (q DispatchQueue) Async(f func()) {}
+
+func (q DispatchQueue) After(d time.Duration, f func()) {}
+
+func (q DispatchQueue) Release() {}
+
+func NewDispatchGroup() DispatchGroup {
+	return 0
+}
+
+func (g DispatchGroup) Async(q DispatchQueue, f func()) {}
+
+func (g DispatchGroup) Notify(q DispatchQueue, f func()) {}
+
+func (g DispatchGroup) Enter() {}
+
+func (g DispatchGroup) Leave() {}
+
+func (g DispatchGroup) Wait(timeout time.Duration) bool {
+	return false
+}
+
+func (g DispatchGroup) Release() {}
+
+func NewDispatchSemaphore(value int) DispatchSemaphore {
+	return 0
+}
+
+func (s DispatchSemaphore) Signal() bool {
+	return false
+}
+
+func (s DispatchSemaphore) Wait(timeout time.Duration) bool {
+	return false
+}
+
+func (s DispatchSemaphore) Release() {}
+
+// Mach time and thread scheduling
+
+func MachAbsoluteTime() uint64 {
+	return 0
+}
+
+func MachTimebaseInfo() MachTimebase {
+	return MachTimebase{}
+}
+
+func MachTimeToDuration(ticks uint64) time.Duration {
+	return 0
+}
+
+func DurationToMachTime(d time.Duration) uint64 {
+	return 0
+}
+
+func MachTimeSince(t uint64) time.Duration {
+	return 0
+}
+
+func SetThreadQoS(qos QoSClass, relativePriority int) error {
+	return unsupported("SetThreadQoS")
+}
+
+func SetThreadTimeConstraint(period, computation, constraint time.Duration, preemptible bool) error {
+	return unsupported("SetThreadTimeConstraint")
+}
+
+// Global state
+
+func RestoreGlobalState() {}
+
+func SetRestoreOnSignal(enabled bool) {}
+
+func SetMouseCursorAssociated(associated bool) error {
+	return unsupported("SetMouseCursorAssociated")
+}
+
+func SetPresentationOptions(opts PresentationOptions) {}
+
+func CaptureDisplay(display CGDirectDisplayID) error {
+	return unsupported("CaptureDisplay")
+}
+
+func ReleaseDisplay(display CGDirectDisplayID) error {
+	return unsupported("ReleaseDisplay")
+}
+
+func SetDisplayGamma(display CGDirectDisplayID, red, green, blue []float32) error {
+	return unsupported("SetDisplayGamma")
+}
+
+func RestoreDisplayGamma() {}
+
+// Dock
+
+func SetDockBadge(label string) {}
+
+func SetDockTileImage(img image.Image) error {
+	return unsupported("SetDockTileImage")
+}
+
+func SetDockProgress(progress float64) error {
+	return unsupported("SetDockProgress")
+}
+
+func SetDockMenu(menu *Menu) {}
@@ -0,0 +1,41 @@
+package darwin
+
+// Class and selector handles are resolved by Initialize on darwin. On other
+// platforms they stay zero so that code referencing them still compiles.
+
+var (
+	Class_NSApplication, Class_NSString, Class_NSWindow, Class_NSPasteboard, Class_NSOpenGLContext, Class_NSObject, Class_NSCursor, Class_NSImage, Class_NSBitmapImageRep, Class_cocoaWindowDelegate, Class_NSMenu, Class_NSMenuItem, Class_appDelegate, Class_menuTarget, Class_NSOpenGLView, Class_NSAutoreleasePool, Class_NSThread, Class_NSOpenGLPixelFormat, Class_NSScreen, Class_NSRunLoop, Class_NSDictionary, Class_NSArray, Class_NSNumber, Class_NSTrackingArea, Class_NSColor, Class_NSImageView, Class_NSError, Class_NSProcessInfo, Class_NSDate, Class_NSEvent uintptr
+)
+
+var (
+	// Application & Lifecycle Selectors
+	Sel_registerName, Sel_alloc, Sel_init, Sel_release, Sel_retain, Sel_autorelease, Sel_drain, Sel_sharedApplication, Sel_setDelegate, Sel_delegate, Sel_setActivationPolicy, Sel_run, Sel_terminate, Sel_stop, Sel_activateIgnoringOtherApps, Sel_applicationDidFinishLaunching, Sel_applicationShouldTerminateAfterLastWindowClosed, Sel_applicationWillTerminate, Sel_new, Sel_isMainThread, Sel_performSelectorOnMainThread, Sel_call, Sel_mainRunLoop, Sel_class,
+	// Menu Selectors
+	Sel_setMainMenu, Sel_addItem, Sel_setSubmenu, Sel_addItemWithTitleActionKeyEquivalent, Sel_hide, Sel_hideOtherApplications, Sel_unhideAllApplications, Sel_miniaturize, Sel_performMiniaturize, Sel_performClose, Sel_selectAll, Sel_copy, Sel_paste, Sel_cut, Sel_undo, Sel_redo, Sel_setServicesMenu, Sel_setWindowsMenu, Sel_setHelpMenu, Sel_orderFrontStandardAboutPanel, Sel_performZoom, Sel_arrangeInFront, Sel_hideCursor,
+	// Window & View Selectors
+	Sel_initWithContentRectStyleMaskBackingDefer, Sel_setTitle, Sel_setContentView, Sel_contentView, Sel_setOpenGLContext, Sel_makeCurrentContext, Sel_update, Sel_prepareOpenGL, Sel_clearCurrentContext, Sel_flushBuffer, Sel_CGLContextObj, Sel_close, Sel_backingScaleFactor, Sel_isKeyWindow, Sel_makeKeyAndOrderFront, Sel_toggleFullScreen, Sel_styleMask, Sel_setAutoresizingMask, Sel_initWithFrame, Sel_frame, Sel_setFrameTopLeftPoint, Sel_nextEventMatchingMaskUntilDateInModeDequeue, Sel_sendEvent, Sel_window, Sel_windowShouldClose, Sel_windowDidResize, Sel_object, Sel_setWantsBestResolutionOpenGLSurface, Sel_makeFirstResponder, Sel_acceptsFirstResponder, Sel_updateTrackingAreas, Sel_addTrackingArea, Sel_initWithRectOptionsOwnerUserInfo, Sel_initWithAttributes, Sel_screen, Sel_mainScreen, Sel_set, Sel_unhide, Sel_viewDidMoveToWindow, Sel_setBackgroundColor, Sel_colorWithSRGB, Sel_setTitlebarAppearsTransparent, Sel_setTitleVisibility, Sel_setWindowLevel, Sel_setCollectionBehavior,
+	// Event Selectors
+	Sel_keyCode, Sel_modifierFlags, Sel_characters, Sel_locationInWindow, Sel_scrollingDeltaX, Sel_scrollingDeltaY, Sel_buttonNumber, Sel_clickCount, Sel_phase, Sel_type, Sel_magnification, Sel_rotation, Sel_mouseMoved, Sel_mouseDragged, Sel_mouseDown, Sel_mouseUp, Sel_rightMouseDown, Sel_rightMouseUp, Sel_scrollWheel, Sel_keyDown, Sel_keyUp, Sel_flagsChanged, Sel_magnifyWithEvent, Sel_rotateWithEvent, Sel_swipeWithEvent, Sel_deltaX, Sel_deltaY,
+	// Drag and Drop Selectors
+	Sel_registerForDraggedTypes, Sel_draggingEntered, Sel_performDragOperation, Sel_concludeDragOperation, Sel_draggingPasteboard,
+	// Pasteboard & String Selectors
+	Sel_generalPasteboard, Sel_stringForType, Sel_setStringForType, Sel_UTF8String, Sel_initWithUTF8String, Sel_pboardTypeFileURL,
+	// Collection & Number Selectors
+	Sel_count, Sel_objectAtIndex, Sel_numberWithInt, Sel_dictionaryWithObjectsForKeysCount, Sel_arrayWithObjects, Sel_unsignedLongLongValue, Sel_deviceDescription,
+	// Error Selectors
+	Sel_domain, Sel_code, Sel_localizedDescription, Sel_userInfo, Sel_allKeys, Sel_objectForKey, Sel_description, Sel_isKindOfClass,
+	// Availability Selectors
+	Sel_processInfo, Sel_operatingSystemVersion, Sel_respondsToSelector, Sel_activate,
+	// Event Loop Selectors
+	Sel_finishLaunching, Sel_updateWindows, Sel_distantPast, Sel_distantFuture, Sel_dateWithTimeIntervalSinceNow, Sel_otherEventWithType, Sel_postEventAtStart, Sel_isRunning,
+	// Global State Selectors
+	Sel_presentationOptions, Sel_setPresentationOptions,
+	// Menu Selectors
+	Sel_menuItemSelected, Sel_setTarget, Sel_setAction, Sel_setTag, Sel_tag, Sel_validateMenuItem, Sel_sendActionToFrom, Sel_targetForAction, Sel_popUpContextMenuWithEventForView, Sel_mouseEventWithType, Sel_convertPointToView, Sel_windowNumber,
+	// Dock Selectors
+	Sel_dockTile, Sel_setBadgeLabel, Sel_display, Sel_size, Sel_addSubview, Sel_applicationIconImage, Sel_setImageScaling, Sel_applicationDockMenu, Sel_setEnabled, Sel_setState, Sel_setHidden, Sel_setKeyEquivalent, Sel_setKeyEquivalentModifierMask, Sel_setImage Selector
+)
+
+var (
+	NSPasteboardTypeFileURL uintptr
+)
@@ -1,94 +1,154 @@
+//go:build darwin
+
 package darwin
 
 import (
+	"context"
 	"runtime"
-	"sync"
+	"sync/atomic"
 
 	"github.com/ebitengine/purego"
 )
 
+// mainQueue is drained by a single CFRunLoopSource on the main run loop. A
+// burst of calls from any number of goroutines is delivered with one signal
+// and one wake-up, and no Objective-C object is allocated per call.
 var (
-	goCallbackFuncs      = make(map[uintptr]func())
-	goCallbackFuncsMtx   sync.Mutex
-	goCallbackFuncsIndex uintptr
-	classGoCallback      uintptr
+	mainQueueSource cfRunLoopSourceRef
+	mainRunLoop     CFRunLoopRef
 )
 
+// MainThread runs f on the main thread and waits for it to finish. Once the
+// application has begun terminating f is dropped and MainThread returns
+// without running it; use MainThreadContext to observe that.
 func MainThread(f func()) {
-	// If we are already on the main thread, execute the function directly to avoid deadlock.
+	_ = MainThreadContext(context.Background(), f)
+}
+
+// MainThreadContext runs f on the main thread and waits for it to finish. It
+// returns ctx.Err() if ctx is done before f starts, and ErrMainLoopStopped if
+// the application terminates first; in both cases f never runs. Once f has
+// started it is always waited for, so f may safely use the caller's data.
+// A panic in f is raised again in the caller as a *MainThreadPanic.
+func MainThreadContext(ctx context.Context, f func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case <-mainLoopStopped:
+		return ErrMainLoopStopped
+	default:
+	}
+
 	runtime.LockOSThread()
 	isMain := isMainThread()
 	runtime.UnlockOSThread()
-
 	if isMain {
-		f()
-		return
+		// Queuing f would wait on the thread that has to run it.
+		if p := runRecovered(f); p != nil {
+			panic(p)
+		}
+		return nil
 	}
 
-	// If we are on a different goroutine, dispatch the function to the main
-	// thread and wait for it to complete.
-	var wg sync.WaitGroup
-	wg.Add(1)
-	dispatch(func() {
-		defer wg.Done()
-		f()
-	})
-	wg.Wait()
-}
-
-func isMainThread() bool {
-	return Objc_sendMsg[bool](Class_NSThread, Sel_isMainThread)
+	c := &mainCall{f: f, done: make(chan struct{})}
+	dispatch(c.run, nil)
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		if c.abandon() {
+			return ctx.Err()
+		}
+		<-c.done
+	case <-mainLoopStopped:
+		if c.abandon() {
+			return ErrMainLoopStopped
+		}
+		<-c.done
+	}
+	if c.recovered != nil {
+		panic(c.recovered)
+	}
+	return nil
 }
 
-func dispatch(f func()) {
-	goCallbackFuncsMtx.Lock()
-	goCallbackFuncsIndex++
-	idx := goCallbackFuncsIndex
-	goCallbackFuncs[idx] = f
-	goCallbackFuncsMtx.Unlock()
+const (
+	callPending int32 = iota
+	callRunning
+	callAbandoned
+)
 
-	cb := Objc_sendMsg[uintptr](classGoCallback, Sel_alloc)
-	cb = Objc_sendMsg[uintptr](cb, Sel_init)
+// mainCall is a synchronous request whose caller may give up on it. Exactly
+// one of run and abandon wins the state transition out of callPending.
+type mainCall struct {
+	state     atomic.Int32
+	f         func()
+	done      chan struct{}
+	recovered *MainThreadPanic
+}
 
-	// Wrap the primitive uintptr in an NSNumber object.
-	nsIdx := Objc_sendMsg[uintptr](Class_NSNumber, Sel_numberWithInt, idx)
+func (c *mainCall) run() {
+	if !c.state.CompareAndSwap(callPending, callRunning) {
+		return
+	}
+	defer close(c.done)
+	c.recovered = runRecovered(c.f)
+}
 
-	Objc_sendMsg[uintptr](cb, Sel_performSelectorOnMainThread, Sel_call, nsIdx, 1)
+func (c *mainCall) abandon() bool {
+	if !c.state.CompareAndSwap(callPending, callAbandoned) {
+		return false
+	}
+	// Drop the reference so an abandoned call still sitting in the queue
+	// does not keep the caller's closure alive.
+	c.f = nil
+	return true
+}
 
-	// We no longer need the manual retain on 'cb'. The system handles it.
-	// The balancing release for 'cb' is still in goCallback.
+// MainThreadAsync schedules f to run on the main thread and returns without
+// waiting for it. Calls made from the same goroutine run in order. Unlike
+// MainThread, f is queued even when the caller is already on the main thread,
+// so it runs on a later pass of the run loop. A panic in f is passed to the
+// handler set with SetMainThreadPanicHandler.
+func MainThreadAsync(f func()) {
+	dispatch(func() { runCallback(f) }, nil)
 }
 
-func goCallback(id, sel, arg uintptr) {
-	// 'arg' is now an NSNumber. We need to extract the integer value.
-	idx := Objc_sendMsg[uintptr](arg, Sel_unsignedLongLongValue)
+func isMainThread() bool {
+	return Objc_sendMsg[bool](Class_NSThread, Sel_isMainThread)
+}
 
-	goCallbackFuncsMtx.Lock()
-	f, ok := goCallbackFuncs[idx]
-	if ok {
-		delete(goCallbackFuncs, idx)
+// checkMainThread is called on entry to every function AppKit requires on the
+// main thread. It costs one atomic load while checks are off.
+func checkMainThread(fn string) {
+	if mainThreadChecksEnabled() && !isMainThread() {
+		reportMainThreadViolation(fn)
 	}
-	goCallbackFuncsMtx.Unlock()
+}
 
-	if ok {
-		f()
+// dispatch queues f for the main thread. If the queue has been closed, f is
+// dropped and reject, if not nil, is called instead.
+func dispatch(f, reject func()) {
+	if mainQueue.push(f, reject) {
+		cfRunLoopSourceSignal(mainQueueSource)
+		cfRunLoopWakeUp(mainRunLoop)
 	}
-
-	// Release the callback object now that we're done with it.
-	Objc_sendMsg[uintptr](id, Sel_release)
 }
 
-func setupGoCallbackClass() {
-	className := "GoCallback"
-	class := objc_allocateClassPair(Class_NSObject, className, 0)
-	if class == 0 {
-		panic("failed to allocate GoCallback class")
+// drainMainQueue is the perform callback of mainQueueSource.
+func drainMainQueue(info uintptr) {
+	batch := mainQueue.take()
+	for _, c := range batch {
+		c.run()
 	}
-	classGoCallback = class
+	mainQueue.recycle(batch)
+}
 
-	ok := class_addMethod(class, Sel_call, purego.NewCallback(goCallback), "v@:@")
-	if !ok {
-		panic("failed to add method 'call' to GoCallback")
+func setupMainQueueSource() {
+	mainRunLoop = cfRunLoopGetMain()
+	mainQueueSource = newRunLoopSource(0, purego.NewCallback(drainMainQueue))
+	if mainQueueSource == 0 {
+		panic("failed to create main queue run loop source")
 	}
-	objc_registerClassPair(class)
+	cfRunLoopAddSource(mainRunLoop, mainQueueSource, kCFRunLoopCommonModes)
 }
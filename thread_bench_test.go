@@ -0,0 +1,116 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ebitengine/purego"
+)
+
+// TestMain keeps the process main thread for the run loop and runs the tests
+// on another goroutine, so MainThread has somewhere to dispatch to.
+func TestMain(m *testing.M) {
+	code := 0
+	Main(func() {
+		setupPerformSelectorClass()
+		code = m.Run()
+	})
+	os.Exit(code)
+}
+
+// The performSelectorOnMainThread dispatcher this package used before the
+// run loop source, kept here as the benchmark baseline.
+var (
+	performSelectorFuncs    = make(map[uintptr]func())
+	performSelectorFuncsMtx sync.Mutex
+	performSelectorIndex    uintptr
+	classPerformSelector    uintptr
+)
+
+func dispatchPerformSelector(f func(), wait bool) {
+	performSelectorFuncsMtx.Lock()
+	performSelectorIndex++
+	idx := performSelectorIndex
+	performSelectorFuncs[idx] = f
+	performSelectorFuncsMtx.Unlock()
+
+	cb := Objc_alloc_init(classPerformSelector)
+	nsIdx := Objc_sendMsg[uintptr](Class_NSNumber, Sel_numberWithInt, idx)
+	Objc_sendMsg[uintptr](cb, Sel_performSelectorOnMainThread, Sel_call, nsIdx, wait)
+}
+
+func performSelectorCallback(id, sel, arg uintptr) {
+	idx := Objc_sendMsg[uintptr](arg, Sel_unsignedLongLongValue)
+	performSelectorFuncsMtx.Lock()
+	f := performSelectorFuncs[idx]
+	delete(performSelectorFuncs, idx)
+	performSelectorFuncsMtx.Unlock()
+	if f != nil {
+		f()
+	}
+	Objc_sendMsg[uintptr](id, Sel_release)
+}
+
+func setupPerformSelectorClass() {
+	class := objc_allocateClassPair(Class_NSObject, "GoBenchPerformSelector", 0)
+	if class == 0 {
+		panic("failed to allocate GoBenchPerformSelector class")
+	}
+	if !class_addMethod(class, Sel_call, purego.NewCallback(performSelectorCallback), "v@:@") {
+		panic("failed to add method 'call' to GoBenchPerformSelector")
+	}
+	objc_registerClassPair(class)
+	classPerformSelector = class
+}
+
+func BenchmarkMainThreadPerformSelector(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var wg sync.WaitGroup
+			wg.Add(1)
+			dispatchPerformSelector(wg.Done, true)
+			wg.Wait()
+		}
+	})
+}
+
+func BenchmarkMainThreadQueue(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			MainThread(func() {})
+		}
+	})
+}
+
+// The async benchmarks issue a frame's worth of calls and wait once for the
+// last of them, which is the pattern the batched queue is built for.
+const benchBurst = 256
+
+func BenchmarkMainThreadAsyncPerformSelector(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var wg sync.WaitGroup
+			wg.Add(benchBurst)
+			for range benchBurst {
+				dispatchPerformSelector(wg.Done, false)
+			}
+			wg.Wait()
+		}
+	})
+}
+
+func BenchmarkMainThreadAsyncQueue(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			var wg sync.WaitGroup
+			wg.Add(benchBurst)
+			for range benchBurst {
+				MainThreadAsync(wg.Done)
+			}
+			wg.Wait()
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package darwin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// ErrNotMainThread is the panic value, wrapped with the function name, raised
+// by MainThreadCheckPanic.
+var ErrNotMainThread = errors.New("darwin: AppKit call made off the main thread")
+
+// MainThreadCheckMode selects what happens when a main-thread-only function
+// is called from another thread.
+type MainThreadCheckMode int32
+
+const (
+	// MainThreadCheckOff skips the check. It is the default unless the
+	// package is built with the darwin_debug tag.
+	MainThreadCheckOff MainThreadCheckMode = iota
+	// MainThreadCheckLog logs the function name and the calling Go stack.
+	MainThreadCheckLog
+	// MainThreadCheckPanic panics with ErrNotMainThread.
+	MainThreadCheckPanic
+)
+
+var mainThreadCheckMode atomic.Int32
+
+// SetMainThreadChecks sets how main-thread-only functions react to being
+// called from another thread. The README lists which functions are checked.
+func SetMainThreadChecks(mode MainThreadCheckMode) {
+	mainThreadCheckMode.Store(int32(mode))
+}
+
+func mainThreadChecksEnabled() bool {
+	return MainThreadCheckMode(mainThreadCheckMode.Load()) != MainThreadCheckOff
+}
+
+func reportMainThreadViolation(fn string) {
+	err := fmt.Errorf("%w: %s", ErrNotMainThread, fn)
+	switch MainThreadCheckMode(mainThreadCheckMode.Load()) {
+	case MainThreadCheckLog:
+		log.Printf("%v\n%s", err, debug.Stack())
+	case MainThreadCheckPanic:
+		panic(err)
+	}
+}
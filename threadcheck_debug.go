@@ -0,0 +1,7 @@
+//go:build darwin_debug
+
+package darwin
+
+func init() {
+	SetMainThreadChecks(MainThreadCheckPanic)
+}
@@ -0,0 +1,168 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ebitengine/purego"
+)
+
+// Timer runs a function once on the main thread after a delay. It is
+// scheduled as a CFRunLoopTimer on the main run loop in the common modes, so
+// it keeps firing during live resize, menu tracking and modal panels.
+type Timer struct {
+	t runLoopTimer
+}
+
+// Ticker runs a function on the main thread at a fixed period, with the same
+// scheduling as Timer.
+type Ticker struct {
+	t runLoopTimer
+}
+
+// AfterFunc calls f on the main thread once d has elapsed.
+func AfterFunc(d time.Duration, f func()) *Timer {
+	tm := &Timer{t: runLoopTimer{f: f}}
+	tm.t.start(d, 0)
+	return tm
+}
+
+// Stop prevents the timer from firing. It reports whether the timer was
+// pending.
+func (tm *Timer) Stop() bool {
+	return tm.t.stop()
+}
+
+// Reset makes the timer fire once after d, whether or not it has already
+// fired or been stopped. It reports whether the timer was pending.
+func (tm *Timer) Reset(d time.Duration) bool {
+	return tm.t.start(d, 0)
+}
+
+// SetTolerance lets the system delay the timer by up to d to coalesce wake-ups
+// and save power. It applies to the current and later schedules.
+func (tm *Timer) SetTolerance(d time.Duration) {
+	tm.t.setTolerance(d)
+}
+
+// NewTicker calls f on the main thread every d. It panics if d <= 0.
+func NewTicker(d time.Duration, f func()) *Ticker {
+	if d <= 0 {
+		panic("darwin: non-positive interval for NewTicker")
+	}
+	tk := &Ticker{t: runLoopTimer{f: f}}
+	tk.t.start(d, d)
+	return tk
+}
+
+// Stop turns off the ticker. It does not wait for a call already running.
+func (tk *Ticker) Stop() {
+	tk.t.stop()
+}
+
+// Reset stops the ticker and restarts it with period d. It panics if d <= 0.
+func (tk *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("darwin: non-positive interval for Ticker.Reset")
+	}
+	tk.t.start(d, d)
+}
+
+// SetTolerance lets the system delay each tick by up to d.
+func (tk *Ticker) SetTolerance(d time.Duration) {
+	tk.t.setTolerance(d)
+}
+
+// runLoopTimer owns one CFRunLoopTimer at a time. Rescheduling invalidates
+// the current timer and creates a new one, since CoreFoundation cannot revive
+// a one-shot timer that has fired.
+type runLoopTimer struct {
+	mu        sync.Mutex
+	ref       cfRunLoopTimerRef
+	id        uintptr
+	f         func()
+	repeating bool
+	tolerance time.Duration
+}
+
+var (
+	timerCalloutOnce sync.Once
+	timerCallout     uintptr
+)
+
+func (t *runLoopTimer) start(d, interval time.Duration) bool {
+	timerCalloutOnce.Do(func() {
+		timerCallout = purego.NewCallback(runLoopTimerFired)
+	})
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	wasActive := t.invalidateLocked()
+
+	if d < 0 {
+		d = 0
+	}
+	t.id = StoreGoPointer(t)
+	t.repeating = interval > 0
+	fireDate := cfAbsoluteTimeGetCurrent() + d.Seconds()
+	t.ref = newRunLoopTimer(fireDate, interval.Seconds(), timerCallout, t.id)
+	if t.tolerance > 0 {
+		cfRunLoopTimerSetTolerance(t.ref, t.tolerance.Seconds())
+	}
+	cfRunLoopAddTimer(cfRunLoopGetMain(), t.ref, kCFRunLoopCommonModes)
+	return wasActive
+}
+
+func (t *runLoopTimer) stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.invalidateLocked()
+}
+
+func (t *runLoopTimer) setTolerance(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tolerance = d
+	if t.ref != 0 {
+		cfRunLoopTimerSetTolerance(t.ref, d.Seconds())
+	}
+}
+
+// invalidateLocked removes the current timer from the run loop and releases
+// it. It reports whether there was one.
+func (t *runLoopTimer) invalidateLocked() bool {
+	if t.ref == 0 {
+		return false
+	}
+	cfRunLoopTimerInvalidate(t.ref)
+	purego.SyscallN(_CFRelease, uintptr(t.ref))
+	FreeGoPointer(t.id)
+	t.ref, t.id = 0, 0
+	return true
+}
+
+// runLoopTimerFired is the CFRunLoopTimer callout shared by every timer. info
+// is the Go pointer ID of the runLoopTimer.
+func runLoopTimerFired(timer, info uintptr) {
+	t, ok := GetGoPointer(info).(*runLoopTimer)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	if t.ref != cfRunLoopTimerRef(timer) {
+		// Stopped or rescheduled after this firing was queued.
+		t.mu.Unlock()
+		return
+	}
+	if !t.repeating {
+		t.invalidateLocked()
+	}
+	f := t.f
+	t.mu.Unlock()
+
+	if p := runRecovered(f); p != nil {
+		handleMainThreadPanic(p)
+	}
+}
@@ -1,6 +1,7 @@
 package darwin
 
 import (
+	"runtime"
 	"sync"
 	"unsafe"
 )
@@ -11,6 +12,15 @@ type IOHIDDeviceRef uintptr
 type CFStringRef uintptr
 type CFArrayRef uintptr
 type CFRunLoopRef uintptr
+type CVDisplayLinkRef uintptr
+type CGDirectDisplayID uint32
+
+// Grand Central Dispatch objects. Each is the native dispatch_*_t handle, so
+// it can be passed to frameworks that take one, such as
+// IOHIDManagerSetDispatchQueue.
+type DispatchQueue uintptr
+type DispatchGroup uintptr
+type DispatchSemaphore uintptr
 
 // Object is a wrapper for a raw Objective-C object pointer.
 type Object struct {
@@ -68,16 +78,21 @@ const (
 )
 
 const (
-	NSBackingStoreBuffered      = 2
-	NSWindowStyleMaskBorderless = 0
-	NSWindowStyleMaskTitled     = 1 << 0
-	NSWindowStyleMaskClosable   = 1 << 1
-	NSWindowStyleMaskResizable  = 1 << 3
-	NSWindowStyleMaskFullScreen = 1 << 14
-	NSEventMaskAny              = 0xFFFFFFFF
-	NSViewWidthSizable          = 2
-	NSViewHeightSizable         = 16
-  NSWindowCollectionBehaviorFullScreenPrimary = 1 << 7
+	NSBackingStoreBuffered                      = 2
+	NSWindowStyleMaskBorderless                 = 0
+	NSWindowStyleMaskTitled                     = 1 << 0
+	NSWindowStyleMaskClosable                   = 1 << 1
+	NSWindowStyleMaskResizable                  = 1 << 3
+	NSWindowStyleMaskFullScreen                 = 1 << 14
+	NSEventMaskAny                              = 0xFFFFFFFF
+	NSViewWidthSizable                          = 2
+	NSViewHeightSizable                         = 16
+	NSWindowCollectionBehaviorFullScreenPrimary = 1 << 7
+)
+
+const (
+	NSEventTypeRightMouseDown     = 3
+	NSEventTypeApplicationDefined = 15
 )
 
 const (
@@ -98,6 +113,10 @@ const (
 	NSTrackingActiveInKeyWindow     = 0x20
 )
 
+const (
+	KCVReturnSuccess = 0
+)
+
 type DarwinCursorMode int
 
 const (
@@ -106,25 +125,181 @@ const (
 	DarwinCursorDisabled
 )
 
+// WindowMap associates native windows with Go values.
+//
+// Deprecated: its keys are raw pointers that dangle once AppKit deallocates
+// the window. Hold a *Weak[NSWindow] instead.
 var (
 	windowMapMtx sync.RWMutex
 	WindowMap    = make(map[NSWindow]any)
 )
 
+// Weak is a non-owning reference to an Objective-C object. It reads as empty
+// once the object has been deallocated, instead of dangling. The zero value
+// refers to nothing. A Weak must not be copied after first use.
+type Weak[T ~struct{ Object }] struct {
+	mu      sync.Mutex
+	loc     *uintptr
+	cleanup runtime.Cleanup
+}
+
 var NSDefaultRunLoopMode uintptr
 
+// RunLoopActivity mirrors CFRunLoopActivity, the stages of a run loop pass
+// reported to observers.
+type RunLoopActivity uint64
+
+const (
+	RunLoopEntry         RunLoopActivity = 1 << 0
+	RunLoopBeforeTimers  RunLoopActivity = 1 << 1
+	RunLoopBeforeSources RunLoopActivity = 1 << 2
+	RunLoopBeforeWaiting RunLoopActivity = 1 << 5
+	RunLoopAfterWaiting  RunLoopActivity = 1 << 6
+	RunLoopExit          RunLoopActivity = 1 << 7
+	RunLoopAllActivities RunLoopActivity = 0x0FFFFFFF
+)
+
+// RunLoopMode names a run loop mode. CoreFoundation compares modes by name,
+// so these match the framework constants of the same name.
+type RunLoopMode string
+
+const (
+	RunLoopDefaultMode       RunLoopMode = "kCFRunLoopDefaultMode"
+	RunLoopCommonModes       RunLoopMode = "kCFRunLoopCommonModes"
+	EventTrackingRunLoopMode RunLoopMode = "NSEventTrackingRunLoopMode"
+	ModalPanelRunLoopMode    RunLoopMode = "NSModalPanelRunLoopMode"
+)
+
+// PresentationOptions is NSApplicationPresentationOptions, which controls the
+// Dock, menu bar and system shortcuts while the application is active.
+type PresentationOptions uint
+
+const (
+	PresentationDefault                    PresentationOptions = 0
+	PresentationAutoHideDock               PresentationOptions = 1 << 0
+	PresentationHideDock                   PresentationOptions = 1 << 1
+	PresentationAutoHideMenuBar            PresentationOptions = 1 << 2
+	PresentationHideMenuBar                PresentationOptions = 1 << 3
+	PresentationDisableAppleMenu           PresentationOptions = 1 << 4
+	PresentationDisableProcessSwitching    PresentationOptions = 1 << 5
+	PresentationDisableForceQuit           PresentationOptions = 1 << 6
+	PresentationDisableSessionTermination  PresentationOptions = 1 << 7
+	PresentationDisableHideApplication     PresentationOptions = 1 << 8
+	PresentationDisableMenuBarTransparency PresentationOptions = 1 << 9
+	PresentationFullScreen                 PresentationOptions = 1 << 10
+	PresentationAutoHideToolbar            PresentationOptions = 1 << 11
+)
+
+// QoSClass is a quality-of-service class from <sys/qos.h>. It ranks work for
+// CPU scheduling, I/O priority and timer coalescing.
+type QoSClass uint32
+
+const (
+	QoSUserInteractive QoSClass = 0x21
+	QoSUserInitiated   QoSClass = 0x19
+	QoSDefault         QoSClass = 0x15
+	QoSUtility         QoSClass = 0x11
+	QoSBackground      QoSClass = 0x09
+	QoSUnspecified     QoSClass = 0x00
+)
+
+// MenuItemState is the checkmark state of a menu item.
+type MenuItemState int
+
+const (
+	MenuItemStateMixed MenuItemState = -1
+	MenuItemStateOff   MenuItemState = 0
+	MenuItemStateOn    MenuItemState = 1
+)
+
+// MenuItem describes one entry of a menu. Selecting it sends Action along the
+// responder chain or, when Action is zero, runs OnSelect or reports Command to
+// the handler set with SetMenuCommandHandler. Accelerator, such as
+// "Cmd+Shift+S", overrides Key and ModifierFlags. Validate, if set, decides
+// whether such an item is enabled each time its menu opens. If Handle is not
+// nil, the builder points it at the native item for later updates. The menu
+// owns the item, so Handle is a weak reference: its Do skips the update once
+// the menu has been rebuilt or released.
 type MenuItem struct {
 	Title         string
 	Action        Selector
 	Key           string
 	IsSeparator   bool
-	Submenu       *ApplicationMenu
+	Submenu       *Menu
 	ModifierFlags []uintptr
+	Accelerator   string
+	OnSelect      func()
+	Command       int
+	Validate      func() bool
+	Handle        *Weak[NSMenuItem]
+
+	// actionName is resolved to Action when the item is built, so menus
+	// such as StandardMenu can be made before Initialize registers the
+	// selectors.
+	actionName string
+}
+
+// MenuRole marks a menu that AppKit manages in addition to its own items.
+type MenuRole int
+
+const (
+	MenuRoleNone MenuRole = iota
+	// MenuRoleWindows registers the menu with setWindowsMenu:, so AppKit
+	// lists open windows in it.
+	MenuRoleWindows
+	// MenuRoleHelp registers the menu with setHelpMenu:, so AppKit adds the
+	// Help search field to it.
+	MenuRoleHelp
+	// MenuRoleServices registers the menu with setServicesMenu:, so AppKit
+	// fills it with the system services.
+	MenuRoleServices
+)
+
+// Menu is a menu and its items, any of which may open a submenu. As the main
+// menu, each item is a top-level entry of the menu bar, in order, and the
+// first one is the application menu. A nil *Menu is a menu with no items.
+type Menu struct {
+	Title string
+	Items []MenuItem
+	Role  MenuRole
 }
 
+// ApplicationMenu is the fixed App, File, Edit and Window layout accepted by
+// SetupApplication. Use Menu for any other layout.
 type ApplicationMenu struct {
 	AppItems    []MenuItem
 	FileItems   []MenuItem
 	EditItems   []MenuItem
 	WindowItems []MenuItem
 }
+
+// Menu converts m to a main menu titled for appName.
+func (m ApplicationMenu) Menu(appName string) *Menu {
+	top := func(title string, items []MenuItem, role MenuRole) MenuItem {
+		return MenuItem{Title: title, Submenu: &Menu{Title: title, Items: items, Role: role}}
+	}
+	return &Menu{Items: []MenuItem{
+		top(appName, m.AppItems, MenuRoleNone),
+		top("File", m.FileItems, MenuRoleNone),
+		top("Edit", m.EditItems, MenuRoleNone),
+		top("Window", m.WindowItems, MenuRoleWindows),
+	}}
+}
+
+// WindowDelegate is a Go interface that our native callbacks will call.
+type WindowDelegate interface {
+	WindowShouldClose()
+	WindowDidResize(resizedWindow NSWindow)
+	KeyDown(event NSEvent)
+	KeyUp(event NSEvent)
+	MouseDown(event NSEvent)
+	MouseUp(event NSEvent)
+	MouseMoved(event NSEvent)
+	MouseDragged(event NSEvent)
+	ScrollWheel(event NSEvent)
+	FlagsChanged(event NSEvent)
+	MagnifyGesture(event NSEvent)
+	RotateGesture(event NSEvent)
+	SwipeGesture(event NSEvent)
+	FilesDropped(files []string)
+}
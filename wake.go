@@ -0,0 +1,29 @@
+package darwin
+
+import "sync/atomic"
+
+// wakeCoalescer keeps at most one wake-up event in the application's event
+// queue. It is re-armed where that event is dequeued, before it is handled,
+// so a post made while the main thread handles it is never lost.
+type wakeCoalescer struct {
+	pending atomic.Bool
+}
+
+// post calls postEvent unless a wake-up is already queued, and reports
+// whether it posted one.
+func (w *wakeCoalescer) post(postEvent func() bool) bool {
+	if !w.pending.CompareAndSwap(false, true) {
+		return false
+	}
+	if !postEvent() {
+		w.pending.Store(false)
+		return false
+	}
+	return true
+}
+
+// rearm lets the next post through. It is called as the wake-up event comes
+// off the queue.
+func (w *wakeCoalescer) rearm() {
+	w.pending.Store(false)
+}
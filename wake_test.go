@@ -0,0 +1,57 @@
+package darwin
+
+import "testing"
+
+func TestWakeCoalescerCoalesces(t *testing.T) {
+	var w wakeCoalescer
+	posted := 0
+	post := func() bool { posted++; return true }
+
+	for range 5 {
+		w.post(post)
+	}
+	if posted != 1 {
+		t.Fatalf("5 posts before the event was dequeued queued %d events, want 1", posted)
+	}
+
+	w.rearm()
+	if !w.post(post) || posted != 2 {
+		t.Fatalf("post after rearm queued %d events in total, want 2", posted)
+	}
+}
+
+func TestWakeCoalescerRearmsBeforeHandling(t *testing.T) {
+	var w wakeCoalescer
+	var queue []string
+	post := func() bool { queue = append(queue, "wake"); return true }
+
+	w.post(post)
+	// The main thread dequeues the wake-up, re-arms, and while it handles
+	// the event another goroutine asks for a wake-up.
+	queue = queue[1:]
+	w.rearm()
+	w.post(post)
+	if len(queue) != 1 {
+		t.Fatalf("post while the wake-up was being handled queued %d events, want 1", len(queue))
+	}
+
+	// A late re-arm, as queued by MainThreadAsync under RunApplication,
+	// can only add a spare wake-up, never drop one.
+	w.rearm()
+	w.post(post)
+	if len(queue) != 2 {
+		t.Fatalf("queue holds %d events after a late re-arm, want 2", len(queue))
+	}
+}
+
+func TestWakeCoalescerFailedPost(t *testing.T) {
+	var w wakeCoalescer
+	if w.post(func() bool { return false }) {
+		t.Fatal("failed post reported success")
+	}
+	posted := false
+	w.post(func() bool { posted = true; return true })
+	if !posted {
+		t.Fatal("failed post left the coalescer armed")
+	}
+}
@@ -0,0 +1,100 @@
+//go:build darwin
+
+package darwin
+
+import (
+	"runtime"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// NewWeak returns a weak reference to obj. The runtime zeroes the reference
+// when obj is deallocated, so Load reports false from then on.
+//
+// The weak slot is registered with the Objective-C runtime and unregistered
+// by Destroy, or by the garbage collector if Destroy is never called.
+func NewWeak[T ~struct{ Object }](obj T) *Weak[T] {
+	w := &Weak[T]{}
+	w.store(obj)
+	return w
+}
+
+// store points w at obj, unregistering whatever it referred to before. The
+// menu builder uses it to fill in MenuItem.Handle.
+func (w *Weak[T]) store(obj T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.loc != nil {
+		w.cleanup.Stop()
+		objc_destroyWeak(w.loc)
+	}
+	// The slot is a separate allocation so the cleanup can hold it without
+	// keeping w reachable. Go heap memory does not move, so its address is
+	// stable for the runtime's side table.
+	loc := new(uintptr)
+	objc_initWeak(loc, uintptr(struct{ Object }(obj).Ptr))
+	w.loc = loc
+	w.cleanup = runtime.AddCleanup(w, objc_destroyWeak, loc)
+}
+
+// Load returns a strong reference to the object, or false if it has been
+// deallocated or the reference was destroyed. The returned object is retained
+// and the caller must Release it.
+func (w *Weak[T]) Load() (T, bool) {
+	var zero T
+	if w == nil {
+		return zero, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.loc == nil {
+		return zero, false
+	}
+	ptr := objc_loadWeakRetained(w.loc)
+	if ptr == 0 {
+		return zero, false
+	}
+	return T(struct{ Object }{Object{unsafe.Pointer(ptr)}}), true
+}
+
+// Do calls f with a strong reference to the object if it is still alive and
+// releases it afterwards. It reports whether f was called.
+func (w *Weak[T]) Do(f func(T)) bool {
+	obj, ok := w.Load()
+	if !ok {
+		return false
+	}
+	defer struct{ Object }(obj).Release()
+	f(obj)
+	return true
+}
+
+// Destroy unregisters the weak reference. Load reports false afterwards.
+// Calling Destroy more than once is harmless.
+func (w *Weak[T]) Destroy() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.loc == nil {
+		return
+	}
+	w.cleanup.Stop()
+	objc_destroyWeak(w.loc)
+	w.loc = nil
+}
+
+func objc_initWeak(location *uintptr, obj uintptr) {
+	purego.SyscallN(objc_initWeak_ptr, uintptr(unsafe.Pointer(location)), obj)
+}
+
+func objc_loadWeakRetained(location *uintptr) uintptr {
+	ret, _, _ := purego.SyscallN(objc_loadWeakRetained_ptr, uintptr(unsafe.Pointer(location)))
+	return ret
+}
+
+func objc_destroyWeak(location *uintptr) {
+	purego.SyscallN(objc_destroyWeak_ptr, uintptr(unsafe.Pointer(location)))
+}
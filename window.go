@@ -1,3 +1,5 @@
+//go:build darwin
+
 package darwin
 
 import (
@@ -10,6 +12,7 @@ import (
 )
 
 func NewSplashWindow(img image.Image) (NSWindow, error) {
+	checkMainThread("NewSplashWindow")
 	width, height := img.Bounds().Dx(), img.Bounds().Dy()
 
 	rect := NSRect{Size: NSSize{Width: float64(width), Height: float64(height)}}
@@ -18,14 +21,14 @@ func NewSplashWindow(img image.Image) (NSWindow, error) {
 
 	winAlloc := Objc_sendMsg[uintptr](Class_NSWindow, Sel_alloc)
 	if winAlloc == 0 {
-		return NSWindow{}, fmt.Errorf("darwin: failed to allocate splash NSWindow")
+		return NSWindow{}, fmt.Errorf("%w: splash NSWindow", ErrAllocFailed)
 	}
 
 	var initWithContentRect func(uintptr, Selector, NSRect, uintptr, uintptr, bool) uintptr
 	purego.RegisterLibFunc(&initWithContentRect, libobjc, "objc_msgSend")
 	win := initWithContentRect(winAlloc, Sel_initWithContentRectStyleMaskBackingDefer, rect, uintptr(styleMask), uintptr(NSBackingStoreBuffered), true)
 	if win == 0 {
-		return NSWindow{}, fmt.Errorf("darwin: failed to initialize splash NSWindow")
+		return NSWindow{}, fmt.Errorf("%w: splash NSWindow", ErrInitFailed)
 	}
 
 	nsWin := NSWindow{Object{unsafe.Pointer(win)}}
@@ -40,7 +43,7 @@ func NewSplashWindow(img image.Image) (NSWindow, error) {
 	nsImage, err := nsImageFromGoImage(img)
 	if err != nil {
 		nsWin.Release()
-		return NSWindow{}, fmt.Errorf("failed to create NSImage for splash: %w", err)
+		return NSWindow{}, fmt.Errorf("darwin: splash image: %w", err)
 	}
 
 	imageViewAlloc := Objc_sendMsg[uintptr](Class_NSImageView, Sel_alloc)
@@ -53,19 +56,20 @@ func NewSplashWindow(img image.Image) (NSWindow, error) {
 }
 
 func NewNSWindow(title string, width, height int) (NSWindow, error) {
+	checkMainThread("NewNSWindow")
 	rect := NSRect{Size: NSSize{Width: float64(width), Height: float64(height)}}
 	styleMask := NSWindowStyleMaskTitled | NSWindowStyleMaskClosable | NSWindowStyleMaskResizable
 
 	winAlloc := Objc_sendMsg[uintptr](Class_NSWindow, Sel_alloc)
 	if winAlloc == 0 {
-		return NSWindow{}, fmt.Errorf("darwin: failed to allocate NSWindow")
+		return NSWindow{}, fmt.Errorf("%w: NSWindow", ErrAllocFailed)
 	}
 
 	var initWithContentRect func(uintptr, Selector, NSRect, uintptr, uintptr, bool) uintptr
 	purego.RegisterLibFunc(&initWithContentRect, libobjc, "objc_msgSend")
 	win := initWithContentRect(winAlloc, Sel_initWithContentRectStyleMaskBackingDefer, rect, uintptr(styleMask), uintptr(NSBackingStoreBuffered), true)
 	if win == 0 {
-		return NSWindow{}, fmt.Errorf("darwin: failed to initialize NSWindow with content rect")
+		return NSWindow{}, fmt.Errorf("%w: NSWindow initWithContentRect:styleMask:backing:defer:", ErrInitFailed)
 	}
 
 	nsWin := NSWindow{Object{unsafe.Pointer(win)}}
@@ -85,6 +89,7 @@ func NewNSWindow(title string, width, height int) (NSWindow, error) {
 }
 
 func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWindow, NSOpenGLView, NSOpenGLContext, error) {
+	checkMainThread("NewNSWindowOpenGL")
 	win, err := NewNSWindow(title, width, height)
 	if err != nil {
 		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, err
@@ -101,7 +106,7 @@ func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWin
 	pixelFormatAlloc := Objc_sendMsg[uintptr](Class_NSOpenGLPixelFormat, Sel_alloc)
 	pixelFormatPtr := Objc_sendMsg[uintptr](pixelFormatAlloc, Sel_initWithAttributes, unsafe.Pointer(&attrs[0]))
 	if pixelFormatPtr == 0 {
-		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, fmt.Errorf("darwin: failed to initialize NSOpenGLPixelFormat")
+		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, fmt.Errorf("%w: NSOpenGLPixelFormat", ErrPixelFormatUnsupported)
 	}
 	pixelFormat := NSOpenGLPixelFormat{Object{unsafe.Pointer(pixelFormatPtr)}}
 	defer pixelFormat.Release()
@@ -109,7 +114,7 @@ func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWin
 	frame := NSRect{Origin: NSPoint{X: 0, Y: 0}, Size: NSSize{Width: float64(width), Height: float64(height)}}
 	view, err := NewCustomOpenGLView(frame, pixelFormat)
 	if err != nil {
-		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, fmt.Errorf("darwin: failed to create custom OpenGL view: %w", err)
+		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, err
 	}
 
 	types := Objc_sendMsg[uintptr](Class_NSArray, Sel_arrayWithObjects, NSPasteboardTypeFileURL, 0)
@@ -121,7 +126,7 @@ func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWin
 	ctxPtr := Objc_sendMsg[uintptr](ctxAlloc, initWithPixelFormatSel, pixelFormatPtr, nil)
 	if ctxPtr == 0 {
 		view.Release()
-		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, fmt.Errorf("darwin: failed to init NSOpenGLContext")
+		return NSWindow{}, NSOpenGLView{}, NSOpenGLContext{}, fmt.Errorf("%w: NSOpenGLContext", ErrInitFailed)
 	}
 	ctx := NSOpenGLContext{Object{unsafe.Pointer(ctxPtr)}}
 	SetContentView(win, view.Object)
@@ -132,9 +137,10 @@ func NewNSWindowOpenGL(title string, width, height int, major, minor int) (NSWin
 }
 
 func NewCustomOpenGLView(frame NSRect, pixelFormat NSOpenGLPixelFormat) (NSOpenGLView, error) {
+	checkMainThread("NewCustomOpenGLView")
 	viewAlloc := Objc_sendMsg[uintptr](Class_cocoaWindowDelegate, Sel_alloc)
 	if viewAlloc == 0 {
-		return NSOpenGLView{}, fmt.Errorf("darwin: failed to allocate CustomOpenGLView")
+		return NSOpenGLView{}, fmt.Errorf("%w: GoCustomOpenGLView", ErrAllocFailed)
 	}
 
 	initWithFramePixelFormatSel := Sel_getUid("initWithFrame:pixelFormat:")
@@ -142,7 +148,7 @@ func NewCustomOpenGLView(frame NSRect, pixelFormat NSOpenGLPixelFormat) (NSOpenG
 	purego.RegisterLibFunc(&initWithFramePixelFormat, libobjc, "objc_msgSend")
 	viewPtr := initWithFramePixelFormat(viewAlloc, initWithFramePixelFormatSel, frame, uintptr(pixelFormat.Ptr))
 	if viewPtr == 0 {
-		return NSOpenGLView{}, fmt.Errorf("darwin: failed to initialize CustomOpenGLView")
+		return NSOpenGLView{}, fmt.Errorf("%w: GoCustomOpenGLView", ErrInitFailed)
 	}
 	view := NSOpenGLView{Object{unsafe.Pointer(viewPtr)}}
 	Objc_sendMsg[uintptr](viewPtr, Sel_setAutoresizingMask, NSViewWidthSizable|NSViewHeightSizable)
@@ -151,20 +157,24 @@ func NewCustomOpenGLView(frame NSRect, pixelFormat NSOpenGLPixelFormat) (NSOpenG
 }
 
 func (w NSWindow) SetTitle(title string) {
+	checkMainThread("NSWindow.SetTitle")
 	nsTitle := NSString_WithUTF8String(title)
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setTitle, uintptr(nsTitle.Ptr))
 }
 
 func (w NSWindow) SetBackgroundColor(r, g, b, a float64) {
+	checkMainThread("NSWindow.SetBackgroundColor")
 	color := Objc_sendMsg[uintptr](Class_NSColor, Sel_colorWithSRGB, r, g, b, a)
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setBackgroundColor, color)
 }
 
 func (w NSWindow) SetTitlebarAppearsTransparent(transparent bool) {
+	checkMainThread("NSWindow.SetTitlebarAppearsTransparent")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setTitlebarAppearsTransparent, transparent)
 }
 
 func (w NSWindow) SetTitleVisibility(visible bool) {
+	checkMainThread("NSWindow.SetTitleVisibility")
 	visibility := NSWindowTitleVisible
 	if !visible {
 		visibility = NSWindowTitleHidden
@@ -173,10 +183,12 @@ func (w NSWindow) SetTitleVisibility(visible bool) {
 }
 
 func (w NSWindow) SetWindowLevel(level int) {
+	checkMainThread("NSWindow.SetWindowLevel")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setWindowLevel, level)
 }
 
 func (w NSWindow) ContentSize() (width, height int, scale float64) {
+	checkMainThread("NSWindow.ContentSize")
 	contentView := Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_contentView)
 	if contentView == 0 {
 		return 0, 0, 1.0
@@ -189,24 +201,29 @@ func (w NSWindow) ContentSize() (width, height int, scale float64) {
 }
 
 func SetContentView(w NSWindow, v Object) {
+	checkMainThread("SetContentView")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setContentView, uintptr(v.Ptr))
 }
 
 func SetOpenGLContext(v NSOpenGLView, ctx NSOpenGLContext) {
+	checkMainThread("SetOpenGLContext")
 	Objc_sendMsg[uintptr](uintptr(v.Ptr), Sel_setOpenGLContext, uintptr(ctx.Ptr))
 }
 
 func SetDelegateAndLinkGo(w NSWindow, delegateAsView NSOpenGLView, goWindow any) {
+	checkMainThread("SetDelegateAndLinkGo")
 	ptrID := StoreGoPointer(goWindow)
 	object_setInstanceVariable(uintptr(delegateAsView.Ptr), "goWindowPtr", unsafe.Pointer(&ptrID))
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_setDelegate, uintptr(delegateAsView.Ptr))
 }
 
 func MakeKeyAndOrderFront(w NSWindow) {
+	checkMainThread("MakeKeyAndOrderFront")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_makeKeyAndOrderFront, 0)
 }
 
 func CloseWindow(w NSWindow) {
+	checkMainThread("CloseWindow")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_close)
 }
 
@@ -223,10 +240,12 @@ func FlushBuffer(ctx NSOpenGLContext) {
 }
 
 func IsKeyWindow(w NSWindow) bool {
+	checkMainThread("IsKeyWindow")
 	return Objc_sendMsg[bool](uintptr(w.Ptr), Sel_isKeyWindow)
 }
 
 func SetWindowFrameTopLeftPoint(w NSWindow, x, y int) {
+	checkMainThread("SetWindowFrameTopLeftPoint")
 	screen := mainNSScreen()
 	screenFrame := screen.Frame()
 	windowFrame := w.Frame()
@@ -239,6 +258,7 @@ func SetWindowFrameTopLeftPoint(w NSWindow, x, y int) {
 }
 
 func WindowFrameTopLeftPoint(w NSWindow) (int, int) {
+	checkMainThread("WindowFrameTopLeftPoint")
 	screen := mainNSScreen()
 	screenFrame := screen.Frame()
 	windowFrame := w.Frame()
@@ -247,36 +267,32 @@ func WindowFrameTopLeftPoint(w NSWindow) (int, int) {
 }
 
 func IsWindowFullscreen(w NSWindow) bool {
+	checkMainThread("IsWindowFullscreen")
 	style := Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_styleMask)
 	return (style & NSWindowStyleMaskFullScreen) != 0
 }
 
 func ToggleWindowFullScreen(w NSWindow) {
+	checkMainThread("ToggleWindowFullScreen")
 	Objc_sendMsg[uintptr](uintptr(w.Ptr), Sel_toggleFullScreen, 0)
 }
 
 func SetCursor(cursor NSCursor) {
+	checkMainThread("SetCursor")
 	Objc_sendMsg[uintptr](uintptr(cursor.Ptr), Sel_set)
 }
 
-func SetCursorMode(mode DarwinCursorMode) {
-	if mode == DarwinCursorHidden || mode == DarwinCursorDisabled {
-		Objc_sendMsg[uintptr](Class_NSCursor, Sel_hide)
-	} else {
-		Objc_sendMsg[uintptr](Class_NSCursor, Sel_unhide)
-	}
-}
-
 func WarpMouseCursorToPoint(x, y float64) {
 	point := NSPoint{X: x, Y: y}
 	purego.SyscallN(_CGWarpMouseCursorPosition, uintptr(unsafe.Pointer(&point)))
 }
 
 func CreateCustomCursor(img image.Image, hotX, hotY int) (NSCursor, error) {
-	return NSCursor{}, fmt.Errorf("CreateCustomCursor is not yet implemented")
+	return NSCursor{}, fmt.Errorf("%w: CreateCustomCursor", ErrNotImplemented)
 }
 
 func SetApplicationIconImageFromImage(img image.Image) error {
+	checkMainThread("SetApplicationIconImageFromImage")
 	nsImg, err := nsImageFromGoImage(img)
 	if err != nil {
 		return err
@@ -314,7 +330,7 @@ func nsImageFromGoImage(img image.Image) (NSImage, error) {
 		&planes, width, height, 8, 4, true, false, uintptr(colorSpace.Ptr), 4*width, 32,
 	)
 	if rep == 0 {
-		return NSImage{}, fmt.Errorf("failed to create NSBitmapImageRep")
+		return NSImage{}, fmt.Errorf("%w: NSBitmapImageRep", ErrImageConversion)
 	}
 
 	nsImgAlloc := Objc_sendMsg[uintptr](Class_NSImage, Sel_alloc)
@@ -328,6 +344,7 @@ func nsImageFromGoImage(img image.Image) (NSImage, error) {
 }
 
 func (w NSWindow) Frame() NSRect {
+	checkMainThread("NSWindow.Frame")
 	var frameFunc func(uintptr, Selector) NSRect
 	purego.RegisterLibFunc(&frameFunc, libobjc, "objc_msgSend")
 	return frameFunc(uintptr(w.Ptr), Sel_frame)
@@ -339,6 +356,7 @@ func mainNSScreen() NSScreen {
 }
 
 func (s NSScreen) Frame() NSRect {
+	checkMainThread("NSScreen.Frame")
 	var frameFunc func(uintptr, Selector) NSRect
 	purego.RegisterLibFunc(&frameFunc, libobjc, "objc_msgSend")
 	return frameFunc(uintptr(s.Ptr), Sel_frame)